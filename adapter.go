@@ -0,0 +1,101 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/KarlGW/azfunc/trigger"
+)
+
+// routeParamsKey is the context key for the route parameters of
+// a request created by AdaptHTTPHandler.
+type routeParamsKey struct{}
+
+// RouteParams returns the route parameters of the HTTP trigger for a
+// request created by AdaptHTTPHandler, or served by a function registered
+// with HTTPHandler.
+func RouteParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(routeParamsKey{}).(map[string]string)
+	return params
+}
+
+// AdaptHTTPHandler returns an HTTPTriggerFunc that runs the provided
+// http.Handler. The *http.Request is created from the HTTP trigger
+// (method, URL, headers, body and route parameters), and the response
+// written by the handler is written to the HTTP output binding.
+func AdaptHTTPHandler(handler http.Handler) HTTPTriggerFunc {
+	return func(ctx *Context, t *trigger.HTTP) error {
+		r, err := newHTTPRequest(ctx, t)
+		if err != nil {
+			return err
+		}
+
+		w := newResponseWriter()
+		handler.ServeHTTP(w, r)
+
+		res := ctx.Outputs.HTTP()
+		for k, v := range w.header {
+			res.Header()[k] = v
+		}
+		res.WriteHeader(w.statusCode)
+		if w.body.Len() > 0 {
+			res.Write(w.body.Bytes())
+		}
+		return nil
+	}
+}
+
+// newHTTPRequest creates an *http.Request from the provided HTTP trigger.
+func newHTTPRequest(ctx context.Context, t *trigger.HTTP) (*http.Request, error) {
+	ctx = context.WithValue(ctx, routeParamsKey{}, t.Params)
+	r, err := http.NewRequestWithContext(ctx, t.Method, t.URL, bytes.NewReader(t.Body))
+	if err != nil {
+		return nil, err
+	}
+	if t.Headers != nil {
+		r.Header = t.Headers.Clone()
+	}
+	r.RequestURI = r.URL.RequestURI()
+	return r, nil
+}
+
+// responseWriter implements http.ResponseWriter and captures the
+// response written by a handler.
+type responseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+}
+
+// newResponseWriter creates a new responseWriter.
+func newResponseWriter() *responseWriter {
+	return &responseWriter{
+		header:     http.Header{},
+		statusCode: http.StatusOK,
+	}
+}
+
+// Header returns the header of the response.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader sets the status code of the response. Only the first
+// call has effect.
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.statusCode = statusCode
+	w.wroteHeader = true
+}
+
+// Write appends the provided data to the body of the response.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(b)
+}
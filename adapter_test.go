@@ -0,0 +1,68 @@
+package azfunc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdaptHTTPHandler(t *testing.T) {
+	var gotRequest struct {
+		method string
+		uri    string
+		header string
+		body   string
+		params map[string]string
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotRequest.method = r.Method
+		gotRequest.uri = r.URL.RequestURI()
+		gotRequest.header = r.Header.Get("X-Test")
+		gotRequest.body = string(b)
+		gotRequest.params = RouteParams(r)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"message":`))
+		w.Write([]byte(`"created"}`))
+	})
+
+	ctx := newContext(context.Background(), func(o *contextOptions) {
+		o.outputs = newOutputs()
+	})
+	tr := &trigger.HTTP{
+		Method:  http.MethodPost,
+		URL:     "http://localhost:7071/api/users/1?verbose=true",
+		Headers: http.Header{"X-Test": []string{"value"}},
+		Params:  map[string]string{"id": "1"},
+		Body:    data.Raw(`{"name":"test"}`),
+	}
+
+	if err := AdaptHTTPHandler(handler)(ctx, tr); err != nil {
+		t.Fatalf("AdaptHTTPHandler() = unexpected error: %v", err)
+	}
+
+	if gotRequest.method != http.MethodPost || gotRequest.uri != "/api/users/1?verbose=true" || gotRequest.header != "value" || gotRequest.body != `{"name":"test"}` {
+		t.Errorf("AdaptHTTPHandler() = unexpected request: %+v\n", gotRequest)
+	}
+	if diff := cmp.Diff(map[string]string{"id": "1"}, gotRequest.params); diff != "" {
+		t.Errorf("RouteParams() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	want := output.NewHTTP(func(o *output.HTTPOptions) {
+		o.StatusCode = http.StatusCreated
+		o.Body = data.Raw(`{"message":"created"}`)
+		o.Header = http.Header{"Content-Type": []string{"application/json"}}
+	})
+	if diff := cmp.Diff(want, ctx.Outputs.HTTP(), cmp.AllowUnexported(output.HTTP{})); diff != "" {
+		t.Errorf("AdaptHTTPHandler() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
@@ -0,0 +1,232 @@
+package azfunc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultReadinessTimeout is the default timeout of the readiness
+	// checks of the FunctionApp.
+	defaultReadinessTimeout = 5 * time.Second
+)
+
+const (
+	// statusOK is the status of a passing health or readiness check.
+	statusOK = "ok"
+	// statusUnavailable is the status of a failing readiness check.
+	statusUnavailable = "unavailable"
+	// statusTimeout is the status of a readiness check that has not
+	// returned within the timeout.
+	statusTimeout = "timeout"
+)
+
+// ReadinessCheck is a function that checks if a dependency of the
+// FunctionApp is ready (e.g. by pinging a database). A returned error
+// marks the FunctionApp as not ready.
+type ReadinessCheck func(ctx context.Context) error
+
+// readinessCheck contains a named ReadinessCheck.
+type readinessCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// WithAdminListener sets the FunctionApp to serve the admin endpoints on
+// the provided address (e.g. ":9090"), separate from the router of the
+// functions that is served on FUNCTIONS_CUSTOMHANDLER_PORT. The endpoints
+// are:
+//   - /healthz: Reports if the FunctionApp is running.
+//   - /readyz: Reports if all readiness checks (WithReadinessCheck) pass.
+//   - /functions: Lists the registered functions and their bindings.
+//   - /metrics and /debug/vars: Metrics, if enabled with WithMetrics.
+func WithAdminListener(addr string) FunctionAppOption {
+	return func(a *functionApp) {
+		a.adminServer = &http.Server{
+			Addr:         addr,
+			ReadTimeout:  defaultReadTimeout,
+			WriteTimeout: defaultWriteTimeout,
+			IdleTimeout:  defaultIdleTimeout,
+		}
+	}
+}
+
+// WithReadinessCheck adds a readiness check with the provided name to the
+// FunctionApp. The checks are run on /readyz of the admin listener. Can be
+// called multiple times to add multiple checks.
+func WithReadinessCheck(name string, check ReadinessCheck) FunctionAppOption {
+	return func(a *functionApp) {
+		if check != nil {
+			a.readinessChecks = append(a.readinessChecks, readinessCheck{name: name, check: check})
+		}
+	}
+}
+
+// adminHandler returns the handler with the admin endpoints of the
+// FunctionApp.
+func (a functionApp) adminHandler() http.Handler {
+	router := http.NewServeMux()
+	router.HandleFunc("/healthz", a.healthz)
+	router.HandleFunc("/readyz", a.readyz)
+	router.HandleFunc("/functions", a.listFunctions)
+	if a.metrics != nil {
+		router.Handle("/metrics", a.metrics.prometheusHandler())
+		router.Handle("/debug/vars", a.metrics.expvarHandler())
+	}
+	return router
+}
+
+// healthResponse is the response of /healthz and /readyz.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthz reports if the FunctionApp is running. It reports unavailable
+// when the FunctionApp is stopping.
+func (a functionApp) healthz(w http.ResponseWriter, r *http.Request) {
+	if a.stopping() {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: statusUnavailable})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: statusOK})
+}
+
+// readyz runs the readiness checks of the FunctionApp concurrently and
+// reports if all of them pass. Checks that have not returned within the
+// timeout fail with the status timeout. It reports unavailable when the
+// FunctionApp is stopping.
+func (a functionApp) readyz(w http.ResponseWriter, r *http.Request) {
+	if a.stopping() {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: statusUnavailable})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), defaultReadinessTimeout)
+	defer cancel()
+
+	res := healthResponse{Status: statusOK}
+	if len(a.readinessChecks) > 0 {
+		res.Checks = make(map[string]string, len(a.readinessChecks))
+	}
+
+	type result struct {
+		name   string
+		status string
+	}
+	// The results channel is buffered, so that checks that have not
+	// returned within the timeout do not block when they do.
+	results := make(chan result, len(a.readinessChecks))
+	for _, rc := range a.readinessChecks {
+		go func(rc readinessCheck) {
+			status := statusOK
+			if err := rc.check(ctx); err != nil {
+				status = err.Error()
+			}
+			results <- result{name: rc.name, status: status}
+		}(rc)
+	}
+
+wait:
+	for range a.readinessChecks {
+		select {
+		case r := <-results:
+			res.Checks[r.name] = r.status
+		case <-ctx.Done():
+			// Checks that have not returned are reported as failed.
+			for _, rc := range a.readinessChecks {
+				if _, ok := res.Checks[rc.name]; !ok {
+					res.Checks[rc.name] = statusTimeout
+				}
+			}
+			break wait
+		}
+	}
+	for _, status := range res.Checks {
+		if status != statusOK {
+			res.Status = statusUnavailable
+		}
+	}
+
+	if res.Status != statusOK {
+		writeJSON(w, http.StatusServiceUnavailable, res)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// stopping returns true if the FunctionApp is stopping.
+func (a functionApp) stopping() bool {
+	if a.draining != nil && a.draining.Load() {
+		return true
+	}
+	return a.ctx != nil && a.ctx.Err() != nil
+}
+
+// functionDescription describes a registered function.
+type functionDescription struct {
+	Name        string               `json:"name"`
+	TriggerType string               `json:"triggerType"`
+	TriggerName string               `json:"triggerName"`
+	Bindings    []bindingDescription `json:"bindings"`
+	Outputs     []string             `json:"outputs"`
+}
+
+// bindingDescription describes a binding of a registered function.
+type bindingDescription struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Direction string `json:"direction"`
+}
+
+// describe returns the description of the function.
+func (f function) describe() functionDescription {
+	info := f.info()
+	d := functionDescription{
+		Name:        info.Name,
+		TriggerType: info.TriggerType,
+		TriggerName: info.TriggerName,
+		Bindings:    []bindingDescription{},
+		Outputs:     []string{},
+	}
+	for _, b := range f.bindings() {
+		d.Bindings = append(d.Bindings, bindingDescription{
+			Name:      b.Name,
+			Type:      b.Type,
+			Direction: b.Direction,
+		})
+	}
+	for _, o := range f.outputs {
+		d.Outputs = append(d.Outputs, o.Name())
+	}
+	if f.poison != nil {
+		d.Outputs = append(d.Outputs, f.poison.output.Name())
+	}
+	return d
+}
+
+// listFunctions lists the registered functions of the FunctionApp, sorted
+// by name.
+func (a functionApp) listFunctions(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(a.functions))
+	for name := range a.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	functions := make([]functionDescription, 0, len(names))
+	for _, name := range names {
+		functions = append(functions, a.functions[name].describe())
+	}
+	writeJSON(w, http.StatusOK, functions)
+}
+
+// writeJSON writes v as JSON with the provided status code to w.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,177 @@
+package azfunc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFunctionApp_Readyz(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	var tests = []struct {
+		name           string
+		options        []FunctionAppOption
+		stop           bool
+		timeout        time.Duration
+		wantStatusCode int
+		want           healthResponse
+	}{
+		{
+			name:           "no checks",
+			wantStatusCode: http.StatusOK,
+			want:           healthResponse{Status: "ok"},
+		},
+		{
+			name: "passing checks",
+			options: []FunctionAppOption{
+				WithReadinessCheck("db", func(ctx context.Context) error {
+					return nil
+				}),
+			},
+			wantStatusCode: http.StatusOK,
+			want: healthResponse{
+				Status: "ok",
+				Checks: map[string]string{"db": "ok"},
+			},
+		},
+		{
+			name: "failing check",
+			options: []FunctionAppOption{
+				WithReadinessCheck("db", func(ctx context.Context) error {
+					return nil
+				}),
+				WithReadinessCheck("cache", func(ctx context.Context) error {
+					return errors.New("connection refused")
+				}),
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+			want: healthResponse{
+				Status: "unavailable",
+				Checks: map[string]string{"db": "ok", "cache": "connection refused"},
+			},
+		},
+		{
+			name: "check that does not return",
+			options: []FunctionAppOption{
+				WithReadinessCheck("db", func(ctx context.Context) error {
+					return nil
+				}),
+				WithReadinessCheck("cache", func(ctx context.Context) error {
+					<-block
+					return nil
+				}),
+			},
+			timeout:        50 * time.Millisecond,
+			wantStatusCode: http.StatusServiceUnavailable,
+			want: healthResponse{
+				Status: "unavailable",
+				Checks: map[string]string{"db": "ok", "cache": "timeout"},
+			},
+		},
+		{
+			name:           "stopping",
+			stop:           true,
+			wantStatusCode: http.StatusServiceUnavailable,
+			want:           healthResponse{Status: "unavailable"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(append([]FunctionAppOption{WithDisableLogging()}, test.options...)...)
+			if test.stop {
+				app.cancel()
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			if test.timeout > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), test.timeout)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			app.adminHandler().ServeHTTP(w, r)
+
+			if test.wantStatusCode != w.Code {
+				t.Errorf("readyz() = unexpected status code, want: %d, got: %d\n", test.wantStatusCode, w.Code)
+			}
+
+			var got healthResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("readyz() = unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("readyz() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_Healthz(t *testing.T) {
+	app := NewFunctionApp(WithDisableLogging())
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	app.adminHandler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("healthz() = unexpected status code, want: %d, got: %d\n", http.StatusOK, w.Code)
+	}
+}
+
+func TestFunctionApp_ListFunctions(t *testing.T) {
+	app := NewFunctionApp(WithDisableLogging())
+	app.AddFunction("hello-http", HTTPTrigger(func(ctx *Context, trigger *trigger.HTTP) error {
+		return nil
+	}))
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		return nil
+	}), WithOutput(output.NewQueue("out")))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/functions", nil)
+	app.adminHandler().ServeHTTP(w, r)
+
+	var got []functionDescription
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("listFunctions() = unexpected error: %v", err)
+	}
+
+	want := []functionDescription{
+		{
+			Name:        "hello-http",
+			TriggerType: "httpTrigger",
+			TriggerName: "req",
+			Bindings: []bindingDescription{
+				{Name: "req", Type: "httpTrigger", Direction: "in"},
+				{Name: "res", Type: "http", Direction: "out"},
+			},
+			Outputs: []string{},
+		},
+		{
+			Name:        "hello-queue",
+			TriggerType: "queueTrigger",
+			TriggerName: "queue",
+			Bindings: []bindingDescription{
+				{Name: "queue", Type: "queueTrigger", Direction: "in"},
+				{Name: "out", Type: "queue", Direction: "out"},
+			},
+			Outputs: []string{"out"},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("listFunctions() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
@@ -1,13 +1,348 @@
 package azfunc
 
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+)
+
+const (
+	// bindingDirectionIn is the direction of trigger and input bindings.
+	bindingDirectionIn = "in"
+	// bindingDirectionOut is the direction of output bindings.
+	bindingDirectionOut = "out"
+)
+
+// binding represents a binding in function.json.
 type binding struct {
-	Name       string   `json:"name,omitempty"`
-	Type       string   `json:"type,omitempty"`
-	Direction  string   `json:"direction,omitempty"`
-	AuthLevel  string   `json:"authLevel,omitempty"`
-	Route      string   `json:"route,omitempty"`
-	Connection string   `json:"connection,omitempty"`
-	QueueName  string   `json:"queueName,omitempty"`
-	TopicName  string   `json:"topicName,omitempty"`
-	Methods    []string `json:"methods,omitempty"`
+	Name                            string         `json:"name,omitempty"`
+	Type                            string         `json:"type,omitempty"`
+	Direction                       string         `json:"direction,omitempty"`
+	AuthLevel                       string         `json:"authLevel,omitempty"`
+	Route                           string         `json:"route,omitempty"`
+	Schedule                        string         `json:"schedule,omitempty"`
+	Path                            string         `json:"path,omitempty"`
+	QueueName                       string         `json:"queueName,omitempty"`
+	TopicName                       string         `json:"topicName,omitempty"`
+	SubscriptionName                string         `json:"subscriptionName,omitempty"`
+	EventHubName                    string         `json:"eventHubName,omitempty"`
+	Connection                      string         `json:"connection,omitempty"`
+	ConsumerGroup                   string         `json:"consumerGroup,omitempty"`
+	Cardinality                     string         `json:"cardinality,omitempty"`
+	DatabaseName                    string         `json:"databaseName,omitempty"`
+	ContainerName                   string         `json:"containerName,omitempty"`
+	LeaseContainerName              string         `json:"leaseContainerName,omitempty"`
+	BrokerList                      string         `json:"brokerList,omitempty"`
+	Topic                           string         `json:"topic,omitempty"`
+	TopicEndpointURI                string         `json:"topicEndpointUri,omitempty"`
+	TopicKeySetting                 string         `json:"topicKeySetting,omitempty"`
+	DataType                        string         `json:"dataType,omitempty"`
+	Methods                         []string       `json:"methods,omitempty"`
+	Properties                      map[string]any `json:"-"`
+	CreateLeaseContainerIfNotExists bool           `json:"createLeaseContainerIfNotExists,omitempty"`
+	RunOnStartup                    bool           `json:"runOnStartup,omitempty"`
+}
+
+// MarshalJSON implements custom marshaling to add the additional
+// properties of the binding after the known fields.
+func (b binding) MarshalJSON() ([]byte, error) {
+	type alias binding
+	data, err := json.Marshal(alias(b))
+	if err != nil || len(b.Properties) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(b.Properties))
+	for k := range b.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, k := range keys {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(b.Properties[k])
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// configurable is the interface that wraps around the method Binding,
+// implemented by the output bindings of the output package.
+type configurable interface {
+	Binding() output.Binding
+}
+
+// outputBinding creates a binding from the provided output binding.
+func outputBinding(o outputable) binding {
+	b := binding{
+		Name:      o.Name(),
+		Direction: bindingDirectionOut,
+	}
+	c, ok := o.(configurable)
+	if !ok {
+		return b
+	}
+	ob := c.Binding()
+	b.Type = ob.Type
+	b.Connection = ob.Connection
+	b.QueueName = ob.QueueName
+	b.TopicName = ob.TopicName
+	b.TopicEndpointURI = ob.TopicEndpointURI
+	b.TopicKeySetting = ob.TopicKeySetting
+	b.BrokerList = ob.BrokerList
+	b.Topic = ob.Topic
+	b.Properties = ob.Properties
+	return b
+}
+
+// applyOptions applies the provided options to opts and returns it.
+func applyOptions[T any, O ~func(*T)](opts T, options []O) T {
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// binding returns the binding of the trigger.
+func (t genericTrigger) binding() binding {
+	opts := applyOptions(trigger.GenericOptions{}, t.options)
+	return binding{
+		Name:       t.name,
+		Type:       opts.Type,
+		Direction:  bindingDirectionIn,
+		Properties: opts.Properties,
+	}
+}
+
+// httpBinding creates the binding of an HTTP trigger with the provided options.
+func httpBinding(options []trigger.HTTPOption) binding {
+	opts := applyOptions(trigger.HTTPOptions{Name: "req", AuthLevel: "function"}, options)
+	return binding{
+		Name:      opts.Name,
+		Type:      "httpTrigger",
+		Direction: bindingDirectionIn,
+		AuthLevel: opts.AuthLevel,
+		Route:     opts.Route,
+		Methods:   opts.Methods,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t httpTrigger) binding() binding {
+	return httpBinding(t.options)
+}
+
+// binding returns the binding of the trigger.
+func (t timerTrigger) binding() binding {
+	opts := applyOptions(trigger.TimerOptions{Name: "timer"}, t.options)
+	return binding{
+		Name:         opts.Name,
+		Type:         "timerTrigger",
+		Direction:    bindingDirectionIn,
+		Schedule:     opts.Schedule,
+		RunOnStartup: opts.RunOnStartup,
+	}
+}
+
+// queueBinding creates the binding of a Queue Storage trigger with the
+// provided name and options.
+func queueBinding(name string, options []trigger.QueueOption) binding {
+	opts := applyOptions(trigger.QueueOptions{}, options)
+	return binding{
+		Name:       name,
+		Type:       "queueTrigger",
+		Direction:  bindingDirectionIn,
+		QueueName:  opts.QueueName,
+		Connection: opts.Connection,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t queueTrigger) binding() binding {
+	return queueBinding(t.name, t.options)
+}
+
+// serviceBusBinding creates the binding of a Service Bus trigger with the
+// provided name and options.
+func serviceBusBinding(name string, options []trigger.ServiceBusOption) binding {
+	opts := applyOptions(trigger.ServiceBusOptions{}, options)
+	return binding{
+		Name:             name,
+		Type:             "serviceBusTrigger",
+		Direction:        bindingDirectionIn,
+		QueueName:        opts.QueueName,
+		TopicName:        opts.TopicName,
+		SubscriptionName: opts.SubscriptionName,
+		Connection:       opts.Connection,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t serviceBusTrigger) binding() binding {
+	return serviceBusBinding(t.name, t.options)
+}
+
+// binding returns the binding of the trigger.
+func (t eventGridTrigger) binding() binding {
+	return binding{
+		Name:      t.name,
+		Type:      "eventGridTrigger",
+		Direction: bindingDirectionIn,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t blobTrigger) binding() binding {
+	opts := applyOptions(trigger.BlobOptions{}, t.options)
+	return binding{
+		Name:       t.name,
+		Type:       "blobTrigger",
+		Direction:  bindingDirectionIn,
+		Path:       opts.Path,
+		Connection: opts.Connection,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t eventHubTrigger) binding() binding {
+	opts := applyOptions(trigger.EventHubOptions{}, t.options)
+	return binding{
+		Name:          t.name,
+		Type:          "eventHubTrigger",
+		Direction:     bindingDirectionIn,
+		EventHubName:  opts.EventHubName,
+		Connection:    opts.Connection,
+		ConsumerGroup: opts.ConsumerGroup,
+		Cardinality:   opts.Cardinality,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t cosmosDBTrigger) binding() binding {
+	opts := applyOptions(trigger.CosmosDBOptions{}, t.options)
+	return binding{
+		Name:                            t.name,
+		Type:                            "cosmosDBTrigger",
+		Direction:                       bindingDirectionIn,
+		Connection:                      opts.Connection,
+		DatabaseName:                    opts.DatabaseName,
+		ContainerName:                   opts.ContainerName,
+		LeaseContainerName:              opts.LeaseContainerName,
+		CreateLeaseContainerIfNotExists: opts.CreateLeaseContainerIfNotExists,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t kafkaTrigger) binding() binding {
+	opts := applyOptions(trigger.KafkaOptions{}, t.options)
+	b := binding{
+		Name:          t.name,
+		Type:          "kafkaTrigger",
+		Direction:     bindingDirectionIn,
+		BrokerList:    opts.BrokerList,
+		Topic:         opts.Topic,
+		ConsumerGroup: opts.ConsumerGroup,
+		Cardinality:   opts.Cardinality,
+	}
+	if opts.Binary {
+		b.DataType = "binary"
+	}
+	return b
+}
+
+// binding returns the binding of the trigger.
+func (t orchestrationTrigger) binding() binding {
+	return binding{
+		Name:      t.name,
+		Type:      "orchestrationTrigger",
+		Direction: bindingDirectionIn,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t activityTrigger) binding() binding {
+	return binding{
+		Name:      t.name,
+		Type:      "activityTrigger",
+		Direction: bindingDirectionIn,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t entityTrigger) binding() binding {
+	return binding{
+		Name:      t.name,
+		Type:      "entityTrigger",
+		Direction: bindingDirectionIn,
+	}
+}
+
+// binding returns the binding of the trigger.
+func (t typedHTTPTrigger[T]) binding() binding {
+	return httpBinding(t.options.HTTPOptions)
+}
+
+// binding returns the binding of the trigger.
+func (t typedQueueTrigger[T]) binding() binding {
+	return queueBinding(t.name, t.options.QueueOptions)
+}
+
+// binding returns the binding of the trigger.
+func (t typedServiceBusTrigger[T]) binding() binding {
+	return serviceBusBinding(t.name, t.options.ServiceBusOptions)
+}
+
+// bindings returns all bindings of the function, starting with the
+// trigger.
+func (f function) bindings() []binding {
+	outputs := make([]binding, 0, len(f.outputs))
+	var hasHTTPOutput bool
+	for _, o := range f.outputs {
+		b := outputBinding(o)
+		if b.Type == "http" {
+			hasHTTPOutput = true
+		}
+		outputs = append(outputs, b)
+	}
+	if f.poison != nil {
+		outputs = append(outputs, outputBinding(f.poison.output))
+	}
+
+	var bindings []binding
+	if f.trigger != nil {
+		b := f.trigger.binding()
+		bindings = append(bindings, b)
+		if b.Type == "httpTrigger" && !hasHTTPOutput {
+			bindings = append(bindings, binding{
+				Name:      "res",
+				Type:      "http",
+				Direction: bindingDirectionOut,
+			})
+		}
+	}
+	if len(f.durableClient) > 0 {
+		bindings = append(bindings, binding{
+			Name:      f.durableClient,
+			Type:      "durableClient",
+			Direction: bindingDirectionIn,
+		})
+	}
+	return append(bindings, outputs...)
 }
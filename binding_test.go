@@ -0,0 +1,128 @@
+package azfunc
+
+import (
+	"testing"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFunction_Bindings(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []FunctionOption
+		want  []binding
+	}{
+		{
+			name: "http trigger",
+			input: []FunctionOption{
+				HTTPTrigger(nil, func(o *trigger.HTTPOptions) {
+					o.Methods = []string{"get", "post"}
+				}),
+			},
+			want: []binding{
+				{Name: "req", Type: "httpTrigger", Direction: "in", AuthLevel: "function", Methods: []string{"get", "post"}},
+				{Name: "res", Type: "http", Direction: "out"},
+			},
+		},
+		{
+			name: "queue trigger with queue output",
+			input: []FunctionOption{
+				QueueTrigger("queue", nil, func(o *trigger.QueueOptions) {
+					o.QueueName = "items"
+					o.Connection = "AzureWebJobsStorage"
+				}),
+				WithOutput(output.NewQueue("outqueue", func(o *output.QueueOptions) {
+					o.QueueName = "out"
+					o.Connection = "AzureWebJobsStorage"
+				})),
+			},
+			want: []binding{
+				{Name: "queue", Type: "queueTrigger", Direction: "in", QueueName: "items", Connection: "AzureWebJobsStorage"},
+				{Name: "outqueue", Type: "queue", Direction: "out", QueueName: "out", Connection: "AzureWebJobsStorage"},
+			},
+		},
+		{
+			name: "timer trigger",
+			input: []FunctionOption{
+				TimerTrigger(nil, func(o *trigger.TimerOptions) {
+					o.Schedule = "0 */1 * * * *"
+				}),
+			},
+			want: []binding{
+				{Name: "timer", Type: "timerTrigger", Direction: "in", Schedule: "0 */1 * * * *"},
+			},
+		},
+		{
+			name: "typed service bus trigger with durable client",
+			input: []FunctionOption{
+				TypedServiceBusTrigger("message", func(ctx *Context, trigger *trigger.ServiceBus, v struct{}) error {
+					return nil
+				}, func(o *TypedTriggerOptions) {
+					o.ServiceBusOptions = []trigger.ServiceBusOption{
+						func(o *trigger.ServiceBusOptions) {
+							o.TopicName = "topic"
+							o.SubscriptionName = "subscription"
+						},
+					}
+				}),
+				WithDurableClient("starter"),
+			},
+			want: []binding{
+				{Name: "message", Type: "serviceBusTrigger", Direction: "in", TopicName: "topic", SubscriptionName: "subscription"},
+				{Name: "starter", Type: "durableClient", Direction: "in"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := function{}
+			for _, option := range test.input {
+				option(&f)
+			}
+
+			got := f.bindings()
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("bindings() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestBinding_MarshalJSON(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input binding
+		want  []byte
+	}{
+		{
+			name:  "binding",
+			input: binding{Name: "queue", Type: "queueTrigger", Direction: "in"},
+			want:  []byte(`{"name":"queue","type":"queueTrigger","direction":"in"}`),
+		},
+		{
+			name: "binding with properties",
+			input: binding{
+				Name:      "table",
+				Type:      "table",
+				Direction: "out",
+				Properties: map[string]any{
+					"tableName":  "items",
+					"connection": "AzureWebJobsStorage",
+				},
+			},
+			want: []byte(`{"name":"table","type":"table","direction":"out","connection":"AzureWebJobsStorage","tableName":"items"}`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, _ := test.input.MarshalJSON()
+			if diff := cmp.Diff(string(test.want), string(got)); diff != "" {
+				t.Errorf("MarshalJSON() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
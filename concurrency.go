@@ -0,0 +1,135 @@
+package azfunc
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrMaxConcurrency is returned when an invocation is rejected because
+// the function has reached its maximum number of concurrent invocations.
+var ErrMaxConcurrency = errors.New("maximum number of concurrent invocations reached")
+
+// ConcurrencyOptions contains options for the concurrency limit of
+// functions.
+type ConcurrencyOptions struct {
+	// Wait sets how long an invocation waits for a running invocation
+	// to finish when the limit has been reached, before it is rejected.
+	// If 0, invocations are rejected immediately (fail fast), so that the
+	// function host retries them.
+	Wait time.Duration
+	// StatusCode sets the status code of the response to rejected
+	// invocations. Defaults to 429 (Too Many Requests). Use 503 (Service
+	// Unavailable) for the function host to treat it as a transient error.
+	StatusCode int
+}
+
+// ConcurrencyOption is a function that sets options for the concurrency
+// limit of functions.
+type ConcurrencyOption func(o *ConcurrencyOptions)
+
+// concurrencyLimit contains the maximum number of concurrent invocations
+// of a function and the behaviour when it has been reached.
+type concurrencyLimit struct {
+	max        int
+	wait       time.Duration
+	statusCode int
+}
+
+// newConcurrencyLimit creates a concurrencyLimit with the provided maximum
+// and options. Returns nil if max is less than 1.
+func newConcurrencyLimit(max int, options ...ConcurrencyOption) *concurrencyLimit {
+	if max < 1 {
+		return nil
+	}
+	opts := ConcurrencyOptions{
+		StatusCode: http.StatusTooManyRequests,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.StatusCode == 0 {
+		opts.StatusCode = http.StatusTooManyRequests
+	}
+	return &concurrencyLimit{
+		max:        max,
+		wait:       opts.Wait,
+		statusCode: opts.StatusCode,
+	}
+}
+
+// WithMaxConcurrency sets the maximum number of concurrent invocations of
+// the function. When it has been reached, new invocations wait or are
+// rejected according to the provided options. Overrides the default
+// of the FunctionApp (WithDefaultMaxConcurrency).
+func WithMaxConcurrency(n int, options ...ConcurrencyOption) FunctionOption {
+	return func(f *function) {
+		f.concurrency = newConcurrencyLimit(n, options...)
+	}
+}
+
+// WithDefaultMaxConcurrency sets the default maximum number of concurrent
+// invocations of every function of the FunctionApp. The limit applies to
+// each function separately.
+func WithDefaultMaxConcurrency(n int, options ...ConcurrencyOption) FunctionAppOption {
+	return func(a *functionApp) {
+		a.concurrency = newConcurrencyLimit(n, options...)
+	}
+}
+
+// concurrencyHandler wraps around the provided http.Handler of a function
+// and limits its number of concurrent invocations with a semaphore. Waiting
+// and rejected invocations are recorded in the metrics.
+func (a functionApp) concurrencyHandler(fn function, h http.Handler) http.Handler {
+	limit := fn.concurrency
+	if limit == nil {
+		limit = a.concurrency
+	}
+	if limit == nil {
+		return h
+	}
+
+	info := fn.info()
+	sem := make(chan struct{}, limit.max)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+		default:
+			if !a.waitConcurrency(info, sem, limit.wait, r) {
+				a.log.Warn(ErrMaxConcurrency.Error(), "function", info.Name)
+				if a.metrics != nil {
+					a.metrics.reject(info)
+				}
+				http.Error(w, ErrMaxConcurrency.Error(), limit.statusCode)
+				return
+			}
+		}
+		defer func() {
+			<-sem
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// waitConcurrency waits for the provided duration to acquire the semaphore.
+// Returns false if it could not be acquired, or the request was cancelled.
+func (a functionApp) waitConcurrency(info FunctionInfo, sem chan struct{}, d time.Duration, r *http.Request) bool {
+	if d <= 0 {
+		return false
+	}
+	if a.metrics != nil {
+		a.metrics.wait(info, 1)
+		defer a.metrics.wait(info, -1)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,126 @@
+package azfunc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFunctionApp_ConcurrencyHandler(t *testing.T) {
+	var tests = []struct {
+		name         string
+		appOptions   []FunctionAppOption
+		options      []FunctionOption
+		release      time.Duration
+		want         int
+		wantRejected uint64
+	}{
+		{
+			name: "unlimited",
+			want: http.StatusOK,
+		},
+		{
+			name:         "fail fast",
+			options:      []FunctionOption{WithMaxConcurrency(1)},
+			want:         http.StatusTooManyRequests,
+			wantRejected: 1,
+		},
+		{
+			name: "fail fast with status code",
+			options: []FunctionOption{WithMaxConcurrency(1, func(o *ConcurrencyOptions) {
+				o.StatusCode = http.StatusServiceUnavailable
+			})},
+			want:         http.StatusServiceUnavailable,
+			wantRejected: 1,
+		},
+		{
+			name: "wait",
+			options: []FunctionOption{WithMaxConcurrency(1, func(o *ConcurrencyOptions) {
+				o.Wait = 5 * time.Second
+			})},
+			release: 20 * time.Millisecond,
+			want:    http.StatusOK,
+		},
+		{
+			name: "wait timeout",
+			options: []FunctionOption{WithMaxConcurrency(1, func(o *ConcurrencyOptions) {
+				o.Wait = 20 * time.Millisecond
+			})},
+			want:         http.StatusTooManyRequests,
+			wantRejected: 1,
+		},
+		{
+			name:         "default of function app",
+			appOptions:   []FunctionAppOption{WithDefaultMaxConcurrency(1)},
+			want:         http.StatusTooManyRequests,
+			wantRejected: 1,
+		},
+		{
+			name:       "function overrides default of function app",
+			appOptions: []FunctionAppOption{WithDefaultMaxConcurrency(1)},
+			options:    []FunctionOption{WithMaxConcurrency(2)},
+			want:       http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			started, release := make(chan struct{}, 2), make(chan struct{})
+			app := NewFunctionApp(append([]FunctionAppOption{WithDisableLogging(), WithMetrics()}, test.appOptions...)...)
+			app.AddFunction("hello-queue", append([]FunctionOption{QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})}, test.options...)...)
+			h := app.handler(app.functions["hello-queue"])
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				h.ServeHTTP(httptest.NewRecorder(), newQueueRequest())
+			}()
+			<-started
+
+			if test.release > 0 {
+				time.AfterFunc(test.release, func() {
+					close(release)
+				})
+			} else {
+				go func() {
+					// Release the running invocation when the second
+					// invocation has started, if it is not rejected.
+					<-started
+					close(release)
+				}()
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, newQueueRequest())
+			if test.want != http.StatusOK {
+				started <- struct{}{}
+			}
+			<-done
+
+			if diff := cmp.Diff(test.want, w.Code); diff != "" {
+				t.Errorf("concurrencyHandler() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			got := app.metrics.expvar()["hello-queue"]
+			if diff := cmp.Diff(test.wantRejected, got.Rejected); diff != "" {
+				t.Errorf("concurrencyHandler() = unexpected rejected (-want +got)\n%s\n", diff)
+			}
+			if got.Waiting != 0 {
+				t.Errorf("concurrencyHandler() = unexpected waiting, want: 0, got: %d\n", got.Waiting)
+			}
+		})
+	}
+}
+
+func newQueueRequest() *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+}
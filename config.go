@@ -0,0 +1,324 @@
+package azfunc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultExtensionBundleVersion is the default version range of the
+	// extension bundle set in host.json.
+	defaultExtensionBundleVersion = "[4.*, 5.0.0)"
+	// extensionBundleID is the ID of the extension bundle set in host.json.
+	extensionBundleID = "Microsoft.Azure.Functions.ExtensionBundle"
+)
+
+// ErrHostConfigMalformed is returned when host.json could not be parsed.
+var ErrHostConfigMalformed = errors.New("host.json malformed")
+
+// functionConfig represents function.json.
+type functionConfig struct {
+	Bindings []binding `json:"bindings"`
+}
+
+// hostConfig represents host.json.
+type hostConfig struct {
+	Version         string                    `json:"version"`
+	ExtensionBundle hostConfigExtensionBundle `json:"extensionBundle"`
+	CustomHandler   hostConfigCustomHandler   `json:"customHandler"`
+}
+
+// hostConfigExtensionBundle represents the extensionBundle section
+// of host.json.
+type hostConfigExtensionBundle struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// hostConfigCustomHandler represents the customHandler section
+// of host.json.
+type hostConfigCustomHandler struct {
+	Description                 hostConfigCustomHandlerDescription `json:"description"`
+	EnableForwardingHTTPRequest bool                               `json:"enableForwardingHttpRequest"`
+}
+
+// hostConfigCustomHandlerDescription represents the description of the
+// customHandler section of host.json.
+type hostConfigCustomHandlerDescription struct {
+	DefaultExecutablePath string   `json:"defaultExecutablePath"`
+	WorkingDirectory      string   `json:"workingDirectory"`
+	Arguments             []string `json:"arguments"`
+}
+
+// ConfigOptions contains options for generating function.json
+// and host.json.
+type ConfigOptions struct {
+	// ExecutablePath sets the path to the executable of the custom
+	// handler. Defaults to the name of the running executable.
+	ExecutablePath string
+	// ExtensionBundleVersion sets the version range of the extension
+	// bundle. Defaults to "[4.*, 5.0.0)". Not applied if an existing
+	// host.json has an extensionBundle.
+	ExtensionBundleVersion string
+	// EnableForwardingHTTPRequest sets if HTTP triggered functions
+	// should receive the original HTTP request. Defaults to true if
+	// any function is registered with HTTPHandler, which requires it.
+	EnableForwardingHTTPRequest bool
+}
+
+// ConfigOption is a function that sets options for generating
+// function.json and host.json.
+type ConfigOption func(o *ConfigOptions)
+
+// GenerateConfig writes host.json and function.json for every function
+// of the FunctionApp to the provided directory. function.json is written
+// to <dir>/<function name>/function.json. If host.json exists, only its
+// customHandler section is updated, and version and extensionBundle are
+// set if missing. Other settings (e.g. functionTimeout) are kept.
+func (a functionApp) GenerateConfig(dir string, options ...ConfigOption) error {
+	if len(a.functions) == 0 {
+		return ErrNoFunction
+	}
+
+	host, err := a.hostConfig(options...)
+	if err != nil {
+		return err
+	}
+	merged, err := mergeHostConfig(filepath.Join(dir, "host.json"), host)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(dir, "host.json"), merged); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(a.functions))
+	for name := range a.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			return err
+		}
+		if err := writeJSONFile(filepath.Join(dir, name, "function.json"), a.functions[name].config()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostConfig creates the host.json of the FunctionApp.
+func (a functionApp) hostConfig(options ...ConfigOption) (hostConfig, error) {
+	handlers, err := a.httpHandlers()
+	if err != nil {
+		return hostConfig{}, err
+	}
+	opts := ConfigOptions{
+		ExtensionBundleVersion:      defaultExtensionBundleVersion,
+		EnableForwardingHTTPRequest: handlers,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if handlers && !opts.EnableForwardingHTTPRequest {
+		return hostConfig{}, ErrHTTPHandlerNotForwarded
+	}
+	if len(opts.ExecutablePath) == 0 {
+		exe, err := os.Executable()
+		if err != nil {
+			return hostConfig{}, err
+		}
+		opts.ExecutablePath = filepath.Base(exe)
+	}
+
+	return hostConfig{
+		Version: "2.0",
+		ExtensionBundle: hostConfigExtensionBundle{
+			ID:      extensionBundleID,
+			Version: opts.ExtensionBundleVersion,
+		},
+		CustomHandler: hostConfigCustomHandler{
+			Description: hostConfigCustomHandlerDescription{
+				DefaultExecutablePath: opts.ExecutablePath,
+				Arguments:             []string{},
+			},
+			EnableForwardingHTTPRequest: opts.EnableForwardingHTTPRequest,
+		},
+	}, nil
+}
+
+// mergeHostConfig merges the provided host.json into the existing host.json
+// with the provided name. The executable path and enableForwardingHttpRequest
+// of the customHandler section are set, and the other generated settings are
+// set if missing. If the file does not exist, the provided host.json is
+// returned.
+func mergeHostConfig(name string, host hostConfig) (any, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return host, nil
+		}
+		return nil, err
+	}
+
+	var existing map[string]any
+	if err := json.Unmarshal(b, &existing); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHostConfigMalformed, err)
+	}
+	if existing == nil {
+		existing = make(map[string]any)
+	}
+	setDefault(existing, "version", host.Version)
+	setDefault(existing, "extensionBundle", host.ExtensionBundle)
+
+	customHandler, _ := existing["customHandler"].(map[string]any)
+	if customHandler == nil {
+		customHandler = make(map[string]any)
+	}
+	description, _ := customHandler["description"].(map[string]any)
+	if description == nil {
+		description = make(map[string]any)
+	}
+	description["defaultExecutablePath"] = host.CustomHandler.Description.DefaultExecutablePath
+	setDefault(description, "workingDirectory", host.CustomHandler.Description.WorkingDirectory)
+	setDefault(description, "arguments", host.CustomHandler.Description.Arguments)
+	customHandler["description"] = description
+	customHandler["enableForwardingHttpRequest"] = host.CustomHandler.EnableForwardingHTTPRequest
+	existing["customHandler"] = customHandler
+
+	return existing, nil
+}
+
+// setDefault sets the value of the key in m if it is not set.
+func setDefault(m map[string]any, key string, v any) {
+	if _, ok := m[key]; !ok {
+		m[key] = v
+	}
+}
+
+// config creates the function.json of the function.
+func (f function) config() functionConfig {
+	bindings := f.bindings()
+	if bindings == nil {
+		bindings = []binding{}
+	}
+	return functionConfig{
+		Bindings: bindings,
+	}
+}
+
+// writeJSONFile writes v as indented JSON to the file with the
+// provided name.
+func writeJSONFile(name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(b, '\n'), 0644)
+}
+
+// readFunctionTimeout reads functionTimeout from the host.json with the
+// provided name. If the file or the setting does not exist, or if it is
+// set to -1 (unbounded), 0 is returned.
+func readFunctionTimeout(name string) (time.Duration, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var host struct {
+		FunctionTimeout string `json:"functionTimeout"`
+	}
+	if err := json.Unmarshal(b, &host); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrHostConfigMalformed, err)
+	}
+	return parseFunctionTimeout(host.FunctionTimeout)
+}
+
+// hostHTTPConfig contains the HTTP settings of host.json.
+type hostHTTPConfig struct {
+	routePrefix                 string
+	enableForwardingHTTPRequest bool
+}
+
+// readHostHTTPConfig reads the route prefix (extensions.http.routePrefix)
+// and enableForwardingHttpRequest from the host.json with the provided name.
+// The route prefix defaults to api. If the file does not exist, the defaults
+// are returned with forwarding enabled.
+func readHostHTTPConfig(name string) (hostHTTPConfig, error) {
+	config := hostHTTPConfig{routePrefix: defaultRoutePrefix}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			config.enableForwardingHTTPRequest = true
+			return config, nil
+		}
+		return hostHTTPConfig{}, err
+	}
+
+	var host struct {
+		Extensions struct {
+			HTTP struct {
+				RoutePrefix *string `json:"routePrefix"`
+			} `json:"http"`
+		} `json:"extensions"`
+		CustomHandler struct {
+			EnableForwardingHTTPRequest bool `json:"enableForwardingHttpRequest"`
+		} `json:"customHandler"`
+	}
+	if err := json.Unmarshal(b, &host); err != nil {
+		return hostHTTPConfig{}, fmt.Errorf("%w: %w", ErrHostConfigMalformed, err)
+	}
+	if host.Extensions.HTTP.RoutePrefix != nil {
+		config.routePrefix = *host.Extensions.HTTP.RoutePrefix
+	}
+	config.enableForwardingHTTPRequest = host.CustomHandler.EnableForwardingHTTPRequest
+	return config, nil
+}
+
+// parseFunctionTimeout parses a timespan in the format of functionTimeout
+// ([d.]hh:mm:ss). If empty or -1 (unbounded), 0 is returned.
+func parseFunctionTimeout(s string) (time.Duration, error) {
+	if len(s) == 0 || s == "-1" {
+		return 0, nil
+	}
+
+	var days int
+	if d, rest, ok := strings.Cut(s, "."); ok && !strings.Contains(d, ":") {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("%w: functionTimeout: %s", ErrHostConfigMalformed, s)
+		}
+		days, s = n, rest
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("%w: functionTimeout: %s", ErrHostConfigMalformed, s)
+	}
+	var values [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("%w: functionTimeout: %s", ErrHostConfigMalformed, s)
+		}
+		values[i] = n
+	}
+
+	return time.Duration(days)*24*time.Hour +
+		time.Duration(values[0])*time.Hour +
+		time.Duration(values[1])*time.Minute +
+		time.Duration(values[2])*time.Second, nil
+}
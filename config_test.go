@@ -0,0 +1,243 @@
+package azfunc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFunctionApp_GenerateConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	app := NewFunctionApp()
+	app.AddFunction(
+		"hello-http-queue",
+		HTTPTrigger(nil, func(o *trigger.HTTPOptions) {
+			o.Methods = []string{"get", "post"}
+		}),
+		WithOutput(output.NewQueue("queue", func(o *output.QueueOptions) {
+			o.QueueName = "out"
+			o.Connection = "AzureWebJobsStorage"
+		})),
+	)
+
+	if err := app.GenerateConfig(dir, func(o *ConfigOptions) {
+		o.ExecutablePath = "handler"
+	}); err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+
+	gotHost, err := os.ReadFile(filepath.Join(dir, "host.json"))
+	if err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(string(hostJSON1), string(gotHost)); diff != "" {
+		t.Errorf("GenerateConfig() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	gotFunction, err := os.ReadFile(filepath.Join(dir, "hello-http-queue", "function.json"))
+	if err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(string(functionJSON1), string(gotFunction)); diff != "" {
+		t.Errorf("GenerateConfig() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestFunctionApp_GenerateConfig_ExistingHostConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "host.json"), hostJSON2, 0644); err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+
+	app := NewFunctionApp()
+	app.AddFunction("hello-http", HTTPTrigger(nil))
+
+	if err := app.GenerateConfig(dir, func(o *ConfigOptions) {
+		o.ExecutablePath = "handler"
+	}); err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "host.json"))
+	if err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"version":         "2.0",
+		"functionTimeout": "00:10:00",
+		"logging": map[string]any{
+			"logLevel": map[string]any{"default": "Warning"},
+		},
+		"extensionBundle": map[string]any{
+			"id":      "Microsoft.Azure.Functions.ExtensionBundle",
+			"version": "[3.*, 4.0.0)",
+		},
+		"customHandler": map[string]any{
+			"description": map[string]any{
+				"defaultExecutablePath": "handler",
+				"workingDirectory":      "",
+				"arguments":             []any{"--verbose"},
+			},
+			"enableForwardingHttpRequest": false,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateConfig() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	timeout, err := readFunctionTimeout(filepath.Join(dir, "host.json"))
+	if err != nil {
+		t.Fatalf("readFunctionTimeout() = unexpected error: %v", err)
+	}
+	if timeout != 10*time.Minute {
+		t.Errorf("readFunctionTimeout() = unexpected result, want: 10m0s, got: %s\n", timeout)
+	}
+}
+
+var hostJSON1 = []byte(`{
+  "version": "2.0",
+  "extensionBundle": {
+    "id": "Microsoft.Azure.Functions.ExtensionBundle",
+    "version": "[4.*, 5.0.0)"
+  },
+  "customHandler": {
+    "description": {
+      "defaultExecutablePath": "handler",
+      "workingDirectory": "",
+      "arguments": []
+    },
+    "enableForwardingHttpRequest": false
+  }
+}
+`)
+
+var hostJSON2 = []byte(`{
+  "version": "2.0",
+  "functionTimeout": "00:10:00",
+  "logging": {
+    "logLevel": {
+      "default": "Warning"
+    }
+  },
+  "extensionBundle": {
+    "id": "Microsoft.Azure.Functions.ExtensionBundle",
+    "version": "[3.*, 4.0.0)"
+  },
+  "customHandler": {
+    "description": {
+      "defaultExecutablePath": "old",
+      "arguments": ["--verbose"]
+    }
+  }
+}
+`)
+
+var functionJSON1 = []byte(`{
+  "bindings": [
+    {
+      "name": "req",
+      "type": "httpTrigger",
+      "direction": "in",
+      "authLevel": "function",
+      "methods": [
+        "get",
+        "post"
+      ]
+    },
+    {
+      "name": "res",
+      "type": "http",
+      "direction": "out"
+    },
+    {
+      "name": "queue",
+      "type": "queue",
+      "direction": "out",
+      "queueName": "out",
+      "connection": "AzureWebJobsStorage"
+    }
+  ]
+}
+`)
+
+func TestParseFunctionTimeout(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    time.Duration
+		wantErr error
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  0,
+		},
+		{
+			name:  "unbounded",
+			input: "-1",
+			want:  0,
+		},
+		{
+			name:  "hh:mm:ss",
+			input: "00:05:30",
+			want:  5*time.Minute + 30*time.Second,
+		},
+		{
+			name:  "d.hh:mm:ss",
+			input: "1.02:00:00",
+			want:  26 * time.Hour,
+		},
+		{
+			name:    "malformed",
+			input:   "5m",
+			wantErr: ErrHostConfigMalformed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := parseFunctionTimeout(test.input)
+
+			if test.want != got {
+				t.Errorf("parseFunctionTimeout() = unexpected result, want: %v, got: %v\n", test.want, got)
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("parseFunctionTimeout() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestReadFunctionTimeout(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "host.json")
+	if err := os.WriteFile(name, []byte(`{"version":"2.0","functionTimeout":"00:10:00"}`), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := readFunctionTimeout(name)
+	if err != nil {
+		t.Fatalf("readFunctionTimeout() = unexpected error: %v", err)
+	}
+	if got != 10*time.Minute {
+		t.Errorf("readFunctionTimeout() = unexpected result, want: %v, got: %v\n", 10*time.Minute, got)
+	}
+
+	got, err = readFunctionTimeout(filepath.Join(dir, "missing.json"))
+	if err != nil || got != 0 {
+		t.Errorf("readFunctionTimeout() = unexpected result, want: 0, got: %v, %v\n", got, err)
+	}
+}
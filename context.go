@@ -2,6 +2,10 @@ package azfunc
 
 import (
 	"context"
+	"net/http"
+	"time"
+
+	"github.com/KarlGW/azfunc/durable"
 )
 
 // Context represents the function context and contains output,
@@ -16,6 +20,23 @@ type Context struct {
 	// clients contains clients defined by the user. It is up to the
 	// user to perform type assertion to handle these services.
 	clients clients
+	// durableClient contains the durable client, if a durable client
+	// binding has been set to the function.
+	durableClient *durable.Client
+	// invocation contains metadata about the invocation.
+	invocation invocation
+	// tracer injects the trace context of the invocation.
+	tracer Tracer
+	// registry contains the dependencies provided to the FunctionApp.
+	registry *registry
+	// scope contains the per invocation dependencies of the invocation.
+	scope *scope
+	// resolving contains the keys of the dependencies being constructed
+	// with the Context, to detect circular dependencies.
+	resolving []dependencyKey
+	// request contains the request of the invocation from the function
+	// host.
+	request *http.Request
 	// Outputs contains output bindings.
 	Outputs *outputs
 }
@@ -35,6 +56,34 @@ func (c *Context) Clients() clients {
 	return c.clients
 }
 
+// InvocationID returns the ID of the invocation, as provided by the
+// function host.
+func (c Context) InvocationID() string {
+	return c.invocation.id
+}
+
+// FunctionName returns the name of the invoked function.
+func (c Context) FunctionName() string {
+	return c.invocation.functionName
+}
+
+// TriggerType returns the type of the trigger of the invoked function
+// (e.g. "httpTrigger", "queueTrigger").
+func (c Context) TriggerType() string {
+	return c.invocation.triggerType
+}
+
+// StartTime returns the time (UTC) the invocation started.
+func (c Context) StartTime() time.Time {
+	return c.invocation.startTime
+}
+
+// DurableClient returns the durable client set in the Context. It is
+// nil if no durable client binding has been set to the function.
+func (c *Context) DurableClient() *durable.Client {
+	return c.durableClient
+}
+
 // SetLogger sets a logger to the Context. Should not be used in most
 // use-cases due to it being set by the FunctionApp.
 func (c *Context) SetLogger(l logger) {
@@ -43,10 +92,15 @@ func (c *Context) SetLogger(l logger) {
 
 // contextOptions contains options for creating a new Context.
 type contextOptions struct {
-	outputs  *outputs
-	log      Logger
-	services services
-	clients  clients
+	outputs       *outputs
+	log           Logger
+	services      services
+	clients       clients
+	durableClient *durable.Client
+	invocation    invocation
+	tracer        Tracer
+	registry      *registry
+	request       *http.Request
 }
 
 // contextOption is a function that sets options on a Context.
@@ -60,18 +114,68 @@ func newContext(ctx context.Context, options ...contextOption) *Context {
 	}
 
 	var c *Context
-	if ctx, ok := ctx.(*Context); !ok {
+	if cctx, ok := ctx.(*Context); ok {
+		c = cctx
+	} else {
 		c = &Context{
 			Context: ctx,
 		}
-	} else {
-		c = ctx
 	}
 
 	c.Outputs = opts.outputs
 	c.log = opts.log
 	c.services = opts.services
 	c.clients = opts.clients
+	c.durableClient = opts.durableClient
+	c.invocation = opts.invocation
+	c.tracer = opts.tracer
+	c.registry = opts.registry
+	c.request = opts.request
+	if c.registry != nil {
+		c.scope = &scope{}
+	}
+
+	if c.invocation != (invocation{}) {
+		args := c.invocation.attrs()
+		c.log = withAttrs(c.log, args...)
+		if c.Outputs != nil {
+			if l, ok := c.Outputs.log.(invocationLogger); ok {
+				c.Outputs.log = l.with(args...)
+			}
+		}
+	}
 
 	return c
 }
+
+// invocation contains metadata about an invocation.
+type invocation struct {
+	startTime    time.Time
+	id           string
+	functionName string
+	triggerType  string
+	// forwarded is set when the invocation is an HTTP request forwarded
+	// by the function host, which is responded to directly.
+	forwarded bool
+}
+
+// spanInfo returns the metadata of the invocation as the SpanInfo of the
+// provided function.
+func (i invocation) spanInfo(info FunctionInfo) SpanInfo {
+	return SpanInfo{
+		FunctionInfo: info,
+		InvocationID: i.id,
+		StartTime:    i.startTime,
+	}
+}
+
+// attrs returns the metadata of the invocation as log attributes
+// (key-value pairs).
+func (i invocation) attrs() []any {
+	return []any{
+		"invocationId", i.id,
+		"functionName", i.functionName,
+		"triggerType", i.triggerType,
+		"startTime", i.startTime,
+	}
+}
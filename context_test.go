@@ -1,11 +1,18 @@
 package azfunc
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/KarlGW/azfunc/data"
 	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -73,3 +80,146 @@ func TestContext_Output_Trigger_Write(t *testing.T) {
 		t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
 	}
 }
+
+func TestContext_Invocation(t *testing.T) {
+	var tests = []struct {
+		name   string
+		header string
+		want   struct {
+			invocationID string
+			functionName string
+			triggerType  string
+		}
+	}{
+		{
+			name:   "invocation ID from header",
+			header: "c1f4b5a0-4a8c-4a8e-9f4b-1f5e8a2f6b1d",
+			want: struct {
+				invocationID string
+				functionName string
+				triggerType  string
+			}{
+				invocationID: "c1f4b5a0-4a8c-4a8e-9f4b-1f5e8a2f6b1d",
+				functionName: "hello-queue",
+				triggerType:  "queueTrigger",
+			},
+		},
+		{
+			name: "generated invocation ID",
+			want: struct {
+				invocationID string
+				functionName string
+				triggerType  string
+			}{
+				functionName: "hello-queue",
+				triggerType:  "queueTrigger",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var invocationID, functionName, triggerType string
+			var startTime time.Time
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				invocationID = ctx.InvocationID()
+				functionName = ctx.FunctionName()
+				triggerType = ctx.TriggerType()
+				startTime = ctx.StartTime()
+				ctx.Outputs.Log().Info("hello")
+				return nil
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+			if len(test.header) > 0 {
+				r.Header.Set("X-Azure-Functions-InvocationId", test.header)
+			}
+			app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+			if len(test.want.invocationID) > 0 && test.want.invocationID != invocationID {
+				t.Errorf("InvocationID() = unexpected result, want: %s, got: %s\n", test.want.invocationID, invocationID)
+			}
+			if len(invocationID) == 0 {
+				t.Errorf("InvocationID() = unexpected result, got empty ID\n")
+			}
+			if test.want.functionName != functionName {
+				t.Errorf("FunctionName() = unexpected result, want: %s, got: %s\n", test.want.functionName, functionName)
+			}
+			if test.want.triggerType != triggerType {
+				t.Errorf("TriggerType() = unexpected result, want: %s, got: %s\n", test.want.triggerType, triggerType)
+			}
+			if startTime.IsZero() || startTime.Location() != time.UTC {
+				t.Errorf("StartTime() = unexpected result, got: %v\n", startTime)
+			}
+
+			var body struct {
+				Logs []string
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("handler() = unexpected error: %v", err)
+			}
+			if len(body.Logs) != 1 {
+				t.Fatalf("Log() = unexpected number of entries, want: 1, got: %d\n", len(body.Logs))
+			}
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(body.Logs[0]), &entry); err != nil {
+				t.Fatalf("Log() = unexpected error: %v", err)
+			}
+
+			want := map[string]any{
+				"invocationId": invocationID,
+				"functionName": functionName,
+				"triggerType":  triggerType,
+				"startTime":    startTime.Format(time.RFC3339Nano),
+			}
+			got := map[string]any{
+				"invocationId": entry["invocationId"],
+				"functionName": entry["functionName"],
+				"triggerType":  entry["triggerType"],
+				"startTime":    entry["startTime"],
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Log() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestWithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l := withAttrs(testLogger{w: &buf}, "invocationId", "1")
+	l.Info("hello", "key", "value")
+
+	want := "hello [invocationId 1 key value]"
+	got := buf.String()
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("withAttrs() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+// testLogger is a Logger that writes the message and arguments to w.
+type testLogger struct {
+	noOpLogger
+	w *bytes.Buffer
+}
+
+func (l testLogger) Info(msg string, args ...any) {
+	fmt.Fprint(l.w, msg, " ", args)
+}
+
+func TestNewContext(t *testing.T) {
+	type key struct{}
+	parent := context.WithValue(context.Background(), key{}, "value")
+	ctx := newContext(parent)
+
+	if got := ctx.Value(key{}); got != "value" {
+		t.Errorf("newContext() = unexpected result, want: value, got: %v\n", got)
+	}
+	if ctx.Done() != nil {
+		t.Errorf("newContext() = unexpected result, want: nil Done channel\n")
+	}
+}
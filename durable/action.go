@@ -0,0 +1,42 @@
+package durable
+
+import (
+	"time"
+)
+
+// ActionType represents the type of an action scheduled by an
+// orchestration.
+type ActionType int
+
+const (
+	// ActionTypeCallActivity calls an activity.
+	ActionTypeCallActivity ActionType = 0
+	// ActionTypeContinueAsNew restarts the orchestration with new input.
+	ActionTypeContinueAsNew ActionType = 4
+	// ActionTypeCreateTimer creates a durable timer.
+	ActionTypeCreateTimer ActionType = 5
+	// ActionTypeWaitForExternalEvent waits for an external event.
+	ActionTypeWaitForExternalEvent ActionType = 6
+)
+
+// Action represents an action scheduled by an orchestration that
+// the durable extension should perform.
+type Action struct {
+	FireAt            *time.Time `json:"fireAt,omitempty"`
+	Input             any        `json:"input,omitempty"`
+	FunctionName      string     `json:"functionName,omitempty"`
+	ExternalEventName string     `json:"externalEventName,omitempty"`
+	Reason            string     `json:"reason,omitempty"`
+	ActionType        ActionType `json:"actionType"`
+	IsCanceled        bool       `json:"isCanceled,omitempty"`
+}
+
+// OrchestratorState represents the result of an execution of an
+// orchestrator function that is returned to the durable extension.
+type OrchestratorState struct {
+	Output       any        `json:"output"`
+	CustomStatus any        `json:"customStatus,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Actions      [][]Action `json:"actions"`
+	IsDone       bool       `json:"isDone"`
+}
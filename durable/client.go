@@ -0,0 +1,324 @@
+package durable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInstanceNotFound is returned when an orchestration instance
+	// could not be found.
+	ErrInstanceNotFound = errors.New("instance not found")
+	// ErrUnexpectedStatusCode is returned when the durable extension
+	// responds with an unexpected status code.
+	ErrUnexpectedStatusCode = errors.New("unexpected status code")
+)
+
+const (
+	// instanceIDPlaceholder is the placeholder for the instance ID
+	// in the management URLs of the binding.
+	instanceIDPlaceholder = "INSTANCEID"
+)
+
+// RuntimeStatus represents the runtime status of an orchestration
+// instance.
+type RuntimeStatus string
+
+const (
+	// RuntimeStatusPending is the status of a scheduled orchestration
+	// instance that has not yet started.
+	RuntimeStatusPending RuntimeStatus = "Pending"
+	// RuntimeStatusRunning is the status of a running orchestration
+	// instance.
+	RuntimeStatusRunning RuntimeStatus = "Running"
+	// RuntimeStatusCompleted is the status of a completed orchestration
+	// instance.
+	RuntimeStatusCompleted RuntimeStatus = "Completed"
+	// RuntimeStatusContinuedAsNew is the status of an orchestration
+	// instance that has been continued as new.
+	RuntimeStatusContinuedAsNew RuntimeStatus = "ContinuedAsNew"
+	// RuntimeStatusFailed is the status of a failed orchestration
+	// instance.
+	RuntimeStatusFailed RuntimeStatus = "Failed"
+	// RuntimeStatusTerminated is the status of a terminated orchestration
+	// instance.
+	RuntimeStatusTerminated RuntimeStatus = "Terminated"
+	// RuntimeStatusSuspended is the status of a suspended orchestration
+	// instance.
+	RuntimeStatusSuspended RuntimeStatus = "Suspended"
+)
+
+// HTTPClient is the interface that wraps around the method Do. It is
+// used by the Client to call the management API of the durable extension.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ClientBinding represents the payload of a durable client binding.
+type ClientBinding struct {
+	TaskHubName                   string         `json:"taskHubName"`
+	BaseURL                       string         `json:"baseUrl"`
+	RequiredQueryStringParameters string         `json:"requiredQueryStringParameters"`
+	CreationURLs                  CreationURLs   `json:"creationUrls"`
+	ManagementURLs                ManagementURLs `json:"managementUrls"`
+}
+
+// CreationURLs contains the URLs for creating orchestration instances.
+type CreationURLs struct {
+	CreateNewInstancePostURI          string `json:"createNewInstancePostUri"`
+	CreateAndWaitOnNewInstancePostURI string `json:"createAndWaitOnNewInstancePostUri"`
+}
+
+// ManagementURLs contains the URLs for managing an orchestration instance.
+type ManagementURLs struct {
+	ID                    string `json:"id"`
+	StatusQueryGetURI     string `json:"statusQueryGetUri"`
+	SendEventPostURI      string `json:"sendEventPostUri"`
+	TerminatePostURI      string `json:"terminatePostUri"`
+	RewindPostURI         string `json:"rewindPostUri,omitempty"`
+	PurgeHistoryDeleteURI string `json:"purgeHistoryDeleteUri"`
+	RestartPostURI        string `json:"restartPostUri,omitempty"`
+	SuspendPostURI        string `json:"suspendPostUri,omitempty"`
+	ResumePostURI         string `json:"resumePostUri,omitempty"`
+}
+
+// OrchestrationStatus represents the status of an orchestration instance.
+type OrchestrationStatus struct {
+	CreatedTime     time.Time       `json:"createdTime"`
+	LastUpdatedTime time.Time       `json:"lastUpdatedTime"`
+	Input           json.RawMessage `json:"input"`
+	CustomStatus    json.RawMessage `json:"customStatus"`
+	Output          json.RawMessage `json:"output"`
+	Name            string          `json:"name"`
+	InstanceID      string          `json:"instanceId"`
+	RuntimeStatus   RuntimeStatus   `json:"runtimeStatus"`
+}
+
+// Client is a client for the management API of the durable extension.
+// It is created from a durable client binding.
+type Client struct {
+	httpClient HTTPClient
+	binding    ClientBinding
+}
+
+// ClientOptions contains options for a Client.
+type ClientOptions struct {
+	// HTTPClient sets the HTTP client used to call the management API.
+	// Defaults to an *http.Client with a timeout of 30 seconds.
+	HTTPClient HTTPClient
+}
+
+// ClientOption is a function that sets options on a Client.
+type ClientOption func(o *ClientOptions)
+
+// NewClient creates a new Client from the provided binding.
+func NewClient(binding ClientBinding, options ...ClientOption) *Client {
+	opts := ClientOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	binding.BaseURL = strings.TrimSuffix(binding.BaseURL, "/")
+	return &Client{
+		httpClient: opts.HTTPClient,
+		binding:    binding,
+	}
+}
+
+// TaskHubName returns the name of the task hub.
+func (c Client) TaskHubName() string {
+	return c.binding.TaskHubName
+}
+
+// ManagementURLs returns the management URLs for the provided instance ID.
+// They can be returned to callers to check the status of the instance.
+func (c Client) ManagementURLs(instanceID string) ManagementURLs {
+	urls := c.binding.ManagementURLs
+	escaped := url.PathEscape(instanceID)
+	replace := func(s string) string {
+		return strings.ReplaceAll(s, instanceIDPlaceholder, escaped)
+	}
+	return ManagementURLs{
+		ID:                    instanceID,
+		StatusQueryGetURI:     replace(urls.StatusQueryGetURI),
+		SendEventPostURI:      replace(urls.SendEventPostURI),
+		TerminatePostURI:      replace(urls.TerminatePostURI),
+		RewindPostURI:         replace(urls.RewindPostURI),
+		PurgeHistoryDeleteURI: replace(urls.PurgeHistoryDeleteURI),
+		RestartPostURI:        replace(urls.RestartPostURI),
+		SuspendPostURI:        replace(urls.SuspendPostURI),
+		ResumePostURI:         replace(urls.ResumePostURI),
+	}
+}
+
+// StartNewOptions contains options for starting a new orchestration
+// instance.
+type StartNewOptions struct {
+	// InstanceID sets the instance ID of the orchestration instance. If not
+	// set, it is generated by the durable extension.
+	InstanceID string
+}
+
+// StartNewOption is a function that sets options for starting a new
+// orchestration instance.
+type StartNewOption func(o *StartNewOptions)
+
+// StartNew starts a new instance of the orchestrator function with the
+// provided name and input. It returns the instance ID.
+func (c Client) StartNew(ctx context.Context, name string, input any, options ...StartNewOption) (string, error) {
+	opts := StartNewOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	path := "/orchestrators/" + url.PathEscape(name)
+	if len(opts.InstanceID) > 0 {
+		path += "/" + url.PathEscape(opts.InstanceID)
+	}
+
+	var urls ManagementURLs
+	if err := c.do(ctx, http.MethodPost, path, nil, input, &urls, http.StatusAccepted); err != nil {
+		return "", err
+	}
+	return urls.ID, nil
+}
+
+// RaiseEvent raises an event with the provided name and data to the
+// orchestration instance.
+func (c Client) RaiseEvent(ctx context.Context, instanceID, eventName string, data any) error {
+	path := "/instances/" + url.PathEscape(instanceID) + "/raiseEvent/" + url.PathEscape(eventName)
+	return c.do(ctx, http.MethodPost, path, nil, data, nil, http.StatusAccepted)
+}
+
+// Status returns the status of the orchestration instance. The status of
+// failed and terminated instances is returned without an error.
+func (c Client) Status(ctx context.Context, instanceID string) (OrchestrationStatus, error) {
+	path := "/instances/" + url.PathEscape(instanceID)
+	statusCode, b, err := c.send(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return OrchestrationStatus{}, err
+	}
+
+	var status OrchestrationStatus
+	switch statusCode {
+	case http.StatusOK, http.StatusAccepted:
+		if err := json.Unmarshal(b, &status); err != nil {
+			return OrchestrationStatus{}, err
+		}
+		return status, nil
+	case http.StatusBadRequest, http.StatusInternalServerError:
+		// The management API responds with 400 (failed or terminated) or
+		// 500 (failed) with the status as body.
+		if err := json.Unmarshal(b, &status); err == nil && len(status.RuntimeStatus) > 0 {
+			return status, nil
+		}
+	}
+	return OrchestrationStatus{}, fmt.Errorf("%w: %d: %s", ErrUnexpectedStatusCode, statusCode, string(b))
+}
+
+// Terminate terminates the orchestration instance with the provided
+// reason.
+func (c Client) Terminate(ctx context.Context, instanceID, reason string) error {
+	path := "/instances/" + url.PathEscape(instanceID) + "/terminate"
+	query := url.Values{}
+	if len(reason) > 0 {
+		query.Set("reason", reason)
+	}
+	return c.do(ctx, http.MethodPost, path, query, nil, nil, http.StatusAccepted)
+}
+
+// Purge purges the history of the orchestration instance.
+func (c Client) Purge(ctx context.Context, instanceID string) error {
+	path := "/instances/" + url.PathEscape(instanceID)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil, http.StatusOK)
+}
+
+// do performs a request against the management API. If the response
+// has one of the expected status codes, the body is decoded into v
+// (if not nil).
+func (c Client) do(ctx context.Context, method, path string, query url.Values, body, v any, expected ...int) error {
+	statusCode, b, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range expected {
+		if statusCode != code {
+			continue
+		}
+		if v == nil || len(b) == 0 {
+			return nil
+		}
+		return json.Unmarshal(b, v)
+	}
+	return fmt.Errorf("%w: %d: %s", ErrUnexpectedStatusCode, statusCode, string(b))
+}
+
+// send performs a request against the management API and returns the
+// status code and body of the response. If the instance is not found,
+// ErrInstanceNotFound is returned.
+func (c Client) send(ctx context.Context, method, path string, query url.Values, body any) (int, []byte, error) {
+	u, err := url.Parse(c.binding.BaseURL + path)
+	if err != nil {
+		return 0, nil, err
+	}
+	required, err := url.ParseQuery(c.binding.RequiredQueryStringParameters)
+	if err != nil {
+		return 0, nil, err
+	}
+	q := u.Query()
+	for _, values := range []url.Values{required, query} {
+		for k, vs := range values {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil, fmt.Errorf("%w: %s", ErrInstanceNotFound, path)
+	}
+	return resp.StatusCode, b, nil
+}
@@ -0,0 +1,211 @@
+package durable
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClient(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+" "+string(b))
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/runtime/webhooks/durabletask/orchestrators/HelloSequence/abc":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":"abc"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/runtime/webhooks/durabletask/instances/abc":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name":"HelloSequence","instanceId":"abc","runtimeStatus":"Completed","output":"Hello Tokyo!","createdTime":"2023-10-12T20:13:49Z","lastUpdatedTime":"2023-10-12T20:13:49Z"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/runtime/webhooks/durabletask/instances/abc":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(ClientBinding{
+		TaskHubName:                   "hub",
+		BaseURL:                       srv.URL + "/runtime/webhooks/durabletask/",
+		RequiredQueryStringParameters: "code=secret",
+	})
+	ctx := context.Background()
+
+	id, err := client.StartNew(ctx, "HelloSequence", "Tokyo", func(o *StartNewOptions) {
+		o.InstanceID = "abc"
+	})
+	if err != nil {
+		t.Fatalf("StartNew() = unexpected error: %v", err)
+	}
+	if id != "abc" {
+		t.Errorf("StartNew() = unexpected result, want: abc, got: %s", id)
+	}
+
+	if err := client.RaiseEvent(ctx, "abc", "Approval", true); err != nil {
+		t.Errorf("RaiseEvent() = unexpected error: %v", err)
+	}
+
+	status, err := client.Status(ctx, "abc")
+	if err != nil {
+		t.Errorf("Status() = unexpected error: %v", err)
+	}
+	wantStatus := OrchestrationStatus{
+		Name:            "HelloSequence",
+		InstanceID:      "abc",
+		RuntimeStatus:   RuntimeStatusCompleted,
+		Output:          []byte(`"Hello Tokyo!"`),
+		CreatedTime:     time.Date(2023, 10, 12, 20, 13, 49, 0, time.UTC),
+		LastUpdatedTime: time.Date(2023, 10, 12, 20, 13, 49, 0, time.UTC),
+	}
+	if diff := cmp.Diff(wantStatus, status); diff != "" {
+		t.Errorf("Status() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	if err := client.Terminate(ctx, "abc", "cancelled"); err != nil {
+		t.Errorf("Terminate() = unexpected error: %v", err)
+	}
+	if err := client.Purge(ctx, "abc"); err != nil {
+		t.Errorf("Purge() = unexpected error: %v", err)
+	}
+	if _, err := client.Status(ctx, "missing"); !errors.Is(err, ErrInstanceNotFound) {
+		t.Errorf("Status() = unexpected error, want: %v, got: %v", ErrInstanceNotFound, err)
+	}
+
+	want := []string{
+		`POST /runtime/webhooks/durabletask/orchestrators/HelloSequence/abc?code=secret "Tokyo"`,
+		`POST /runtime/webhooks/durabletask/instances/abc/raiseEvent/Approval?code=secret true`,
+		`GET /runtime/webhooks/durabletask/instances/abc?code=secret `,
+		`POST /runtime/webhooks/durabletask/instances/abc/terminate?code=secret&reason=cancelled `,
+		`DELETE /runtime/webhooks/durabletask/instances/abc?code=secret `,
+		`GET /runtime/webhooks/durabletask/instances/missing?code=secret `,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Client = unexpected requests (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestClient_Status(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			statusCode int
+			body       string
+		}
+		want    OrchestrationStatus
+		wantErr error
+	}{
+		{
+			name: "running",
+			input: struct {
+				statusCode int
+				body       string
+			}{
+				statusCode: http.StatusAccepted,
+				body:       `{"instanceId":"abc","runtimeStatus":"Running"}`,
+			},
+			want: OrchestrationStatus{InstanceID: "abc", RuntimeStatus: RuntimeStatusRunning},
+		},
+		{
+			name: "failed",
+			input: struct {
+				statusCode int
+				body       string
+			}{
+				statusCode: http.StatusInternalServerError,
+				body:       `{"instanceId":"abc","runtimeStatus":"Failed","output":"error"}`,
+			},
+			want: OrchestrationStatus{InstanceID: "abc", RuntimeStatus: RuntimeStatusFailed, Output: []byte(`"error"`)},
+		},
+		{
+			name: "terminated",
+			input: struct {
+				statusCode int
+				body       string
+			}{
+				statusCode: http.StatusBadRequest,
+				body:       `{"instanceId":"abc","runtimeStatus":"Terminated","output":"cancelled"}`,
+			},
+			want: OrchestrationStatus{InstanceID: "abc", RuntimeStatus: RuntimeStatusTerminated, Output: []byte(`"cancelled"`)},
+		},
+		{
+			name: "bad request",
+			input: struct {
+				statusCode int
+				body       string
+			}{
+				statusCode: http.StatusBadRequest,
+				body:       `{"message":"bad request"}`,
+			},
+			wantErr: ErrUnexpectedStatusCode,
+		},
+		{
+			name: "internal server error",
+			input: struct {
+				statusCode int
+				body       string
+			}{
+				statusCode: http.StatusInternalServerError,
+				body:       `error`,
+			},
+			wantErr: ErrUnexpectedStatusCode,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.input.statusCode)
+				w.Write([]byte(test.input.body))
+			}))
+			defer srv.Close()
+
+			client := NewClient(ClientBinding{BaseURL: srv.URL}, func(o *ClientOptions) {
+				o.HTTPClient = srv.Client()
+			})
+			got, gotErr := client.Status(context.Background(), "abc")
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Status() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Status() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestClient_ManagementURLs(t *testing.T) {
+	client := NewClient(ClientBinding{
+		ManagementURLs: ManagementURLs{
+			ID:                    "INSTANCEID",
+			StatusQueryGetURI:     "http://localhost:7071/runtime/webhooks/durabletask/instances/INSTANCEID?code=secret",
+			SendEventPostURI:      "http://localhost:7071/runtime/webhooks/durabletask/instances/INSTANCEID/raiseEvent/{eventName}?code=secret",
+			TerminatePostURI:      "http://localhost:7071/runtime/webhooks/durabletask/instances/INSTANCEID/terminate?reason={text}&code=secret",
+			PurgeHistoryDeleteURI: "http://localhost:7071/runtime/webhooks/durabletask/instances/INSTANCEID?code=secret",
+		},
+	})
+
+	want := ManagementURLs{
+		ID:                    "abc",
+		StatusQueryGetURI:     "http://localhost:7071/runtime/webhooks/durabletask/instances/abc?code=secret",
+		SendEventPostURI:      "http://localhost:7071/runtime/webhooks/durabletask/instances/abc/raiseEvent/{eventName}?code=secret",
+		TerminatePostURI:      "http://localhost:7071/runtime/webhooks/durabletask/instances/abc/terminate?reason={text}&code=secret",
+		PurgeHistoryDeleteURI: "http://localhost:7071/runtime/webhooks/durabletask/instances/abc?code=secret",
+	}
+
+	if diff := cmp.Diff(want, client.ManagementURLs("abc")); diff != "" {
+		t.Errorf("ManagementURLs() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
@@ -0,0 +1,206 @@
+package durable
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EntityID represents the ID of a durable entity.
+type EntityID struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// EntityRequest represents the payload of an entity trigger.
+type EntityRequest struct {
+	Self   EntityID          `json:"self"`
+	State  *string           `json:"state"`
+	Batch  []EntityOperation `json:"batch"`
+	Exists bool              `json:"exists"`
+}
+
+// EntityOperation represents an operation in a batch of operations
+// sent to an entity.
+type EntityOperation struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Input  string `json:"input"`
+	Signal bool   `json:"signal"`
+}
+
+// EntityState represents the result of an execution of an
+// entity function that is returned to the durable extension.
+type EntityState struct {
+	EntityState  *string                 `json:"entityState,omitempty"`
+	Results      []EntityOperationResult `json:"results"`
+	Signals      []EntitySignal          `json:"signals"`
+	EntityExists bool                    `json:"entityExists"`
+}
+
+// EntityOperationResult represents the result of an operation.
+type EntityOperationResult struct {
+	Result   string `json:"result,omitempty"`
+	Duration int64  `json:"duration"`
+	IsError  bool   `json:"isError"`
+}
+
+// EntitySignal represents a signal sent from an entity to
+// another entity.
+type EntitySignal struct {
+	Target EntityID `json:"target"`
+	Name   string   `json:"name"`
+	Input  string   `json:"input,omitempty"`
+}
+
+// EntityContext is the context of a durable entity. The entity
+// function is run once for every operation in the batch.
+type EntityContext struct {
+	self      EntityID
+	state     *string
+	operation EntityOperation
+	batch     []EntityOperation
+	result    *string
+	signals   []EntitySignal
+	exists    bool
+}
+
+// NewEntityContext creates a new EntityContext from the provided
+// request.
+func NewEntityContext(req EntityRequest) *EntityContext {
+	return &EntityContext{
+		self:   req.Self,
+		state:  req.State,
+		batch:  req.Batch,
+		exists: req.Exists && req.State != nil,
+	}
+}
+
+// ID returns the ID of the entity.
+func (c EntityContext) ID() EntityID {
+	return c.self
+}
+
+// Name returns the name of the entity.
+func (c EntityContext) Name() string {
+	return c.self.Name
+}
+
+// Key returns the key of the entity.
+func (c EntityContext) Key() string {
+	return c.self.Key
+}
+
+// OperationName returns the name of the current operation.
+func (c EntityContext) OperationName() string {
+	return c.operation.Name
+}
+
+// Input parses the input of the current operation into the provided
+// value.
+func (c EntityContext) Input(v any) error {
+	if len(c.operation.Input) == 0 {
+		return nil
+	}
+	return json.Unmarshal([]byte(c.operation.Input), &v)
+}
+
+// Exists returns true if the entity has state.
+func (c EntityContext) Exists() bool {
+	return c.exists
+}
+
+// State parses the state of the entity into the provided value. If
+// the entity has no state, the value is left unchanged.
+func (c EntityContext) State(v any) error {
+	if !c.exists || c.state == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(*c.state), &v)
+}
+
+// SetState sets the state of the entity.
+func (c *EntityContext) SetState(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	state := string(b)
+	c.state = &state
+	c.exists = true
+	return nil
+}
+
+// DestructOnExit deletes the state of the entity. Setting the state
+// again in a later operation recreates it.
+func (c *EntityContext) DestructOnExit() {
+	c.state = nil
+	c.exists = false
+}
+
+// Return sets the result of the current operation.
+func (c *EntityContext) Return(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	result := string(b)
+	c.result = &result
+	return nil
+}
+
+// SignalEntity sends a signal with the provided operation and input
+// to the entity with the provided ID.
+func (c *EntityContext) SignalEntity(id EntityID, operation string, input any) error {
+	signal := EntitySignal{
+		Target: id,
+		Name:   operation,
+	}
+	if input != nil {
+		b, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		signal.Input = string(b)
+	}
+	c.signals = append(c.signals, signal)
+	return nil
+}
+
+// Run runs the provided entity function once for every operation in the
+// batch and returns the resulting state to return to the durable
+// extension. If an operation fails, changes to the state and signals
+// made by that operation are rolled back.
+func (c *EntityContext) Run(fn func() error) EntityState {
+	results := make([]EntityOperationResult, 0, len(c.batch))
+	for _, operation := range c.batch {
+		state, exists, signals := c.state, c.exists, len(c.signals)
+		c.operation = operation
+		c.result = nil
+
+		start := time.Now()
+		err := fn()
+		result := EntityOperationResult{
+			Duration: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			c.state, c.exists, c.signals = state, exists, c.signals[:signals]
+			b, _ := json.Marshal(err.Error())
+			result.Result = string(b)
+			result.IsError = true
+		} else if c.result != nil {
+			result.Result = *c.result
+		}
+		results = append(results, result)
+	}
+
+	signals := c.signals
+	if signals == nil {
+		signals = []EntitySignal{}
+	}
+	return EntityState{
+		EntityExists: c.exists,
+		EntityState:  c.state,
+		Results:      results,
+		Signals:      signals,
+	}
+}
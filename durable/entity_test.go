@@ -0,0 +1,117 @@
+package durable
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEntityContext_Run(t *testing.T) {
+	counter := func(c *EntityContext) error {
+		var n int
+		if err := c.State(&n); err != nil {
+			return err
+		}
+		switch c.OperationName() {
+		case "add":
+			var amount int
+			if err := c.Input(&amount); err != nil {
+				return err
+			}
+			if amount < 0 {
+				c.SignalEntity(EntityID{Name: "audit", Key: "1"}, "negative", amount)
+				return errors.New("negative amount")
+			}
+			return c.SetState(n + amount)
+		case "get":
+			return c.Return(n)
+		case "delete":
+			c.DestructOnExit()
+			return nil
+		}
+		return errors.New("unknown operation")
+	}
+
+	var tests = []struct {
+		name  string
+		input EntityRequest
+		want  EntityState
+	}{
+		{
+			name: "new entity",
+			input: EntityRequest{
+				Self: EntityID{Name: "counter", Key: "1"},
+				Batch: []EntityOperation{
+					{ID: "1", Name: "add", Input: "5", Signal: true},
+					{ID: "2", Name: "get"},
+				},
+			},
+			want: EntityState{
+				EntityExists: true,
+				EntityState:  ptr("5"),
+				Results: []EntityOperationResult{
+					{},
+					{Result: "5"},
+				},
+				Signals: []EntitySignal{},
+			},
+		},
+		{
+			name: "existing entity with failed operation",
+			input: EntityRequest{
+				Self:   EntityID{Name: "counter", Key: "1"},
+				State:  ptr("5"),
+				Exists: true,
+				Batch: []EntityOperation{
+					{ID: "1", Name: "add", Input: "-1", Signal: true},
+					{ID: "2", Name: "add", Input: "2", Signal: true},
+				},
+			},
+			want: EntityState{
+				EntityExists: true,
+				EntityState:  ptr("7"),
+				Results: []EntityOperationResult{
+					{Result: `"negative amount"`, IsError: true},
+					{},
+				},
+				Signals: []EntitySignal{},
+			},
+		},
+		{
+			name: "delete entity",
+			input: EntityRequest{
+				Self:   EntityID{Name: "counter", Key: "1"},
+				State:  ptr("5"),
+				Exists: true,
+				Batch: []EntityOperation{
+					{ID: "1", Name: "delete", Signal: true},
+				},
+			},
+			want: EntityState{
+				Results: []EntityOperationResult{
+					{},
+				},
+				Signals: []EntitySignal{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewEntityContext(test.input)
+			got := c.Run(func() error {
+				return counter(c)
+			})
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreFields(EntityOperationResult{}, "Duration")); diff != "" {
+				t.Errorf("Run() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
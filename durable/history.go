@@ -0,0 +1,79 @@
+package durable
+
+import (
+	"time"
+)
+
+// EventType represents the type of a history event.
+type EventType int
+
+const (
+	// EventTypeExecutionStarted is the event type for when an orchestration
+	// has been started.
+	EventTypeExecutionStarted EventType = iota
+	// EventTypeExecutionCompleted is the event type for when an orchestration
+	// has completed.
+	EventTypeExecutionCompleted
+	// EventTypeExecutionFailed is the event type for when an orchestration
+	// has failed.
+	EventTypeExecutionFailed
+	// EventTypeExecutionTerminated is the event type for when an orchestration
+	// has been terminated.
+	EventTypeExecutionTerminated
+	// EventTypeTaskScheduled is the event type for when an activity has
+	// been scheduled.
+	EventTypeTaskScheduled
+	// EventTypeTaskCompleted is the event type for when an activity has
+	// completed.
+	EventTypeTaskCompleted
+	// EventTypeTaskFailed is the event type for when an activity has failed.
+	EventTypeTaskFailed
+	// EventTypeSubOrchestrationInstanceCreated is the event type for when a
+	// sub-orchestration has been created.
+	EventTypeSubOrchestrationInstanceCreated
+	// EventTypeSubOrchestrationInstanceCompleted is the event type for when a
+	// sub-orchestration has completed.
+	EventTypeSubOrchestrationInstanceCompleted
+	// EventTypeSubOrchestrationInstanceFailed is the event type for when a
+	// sub-orchestration has failed.
+	EventTypeSubOrchestrationInstanceFailed
+	// EventTypeTimerCreated is the event type for when a timer has been
+	// created.
+	EventTypeTimerCreated
+	// EventTypeTimerFired is the event type for when a timer has fired.
+	EventTypeTimerFired
+	// EventTypeOrchestratorStarted is the event type for when an execution
+	// of the orchestrator function has started.
+	EventTypeOrchestratorStarted
+	// EventTypeOrchestratorCompleted is the event type for when an execution
+	// of the orchestrator function has completed.
+	EventTypeOrchestratorCompleted
+	// EventTypeEventSent is the event type for when an event has been sent.
+	EventTypeEventSent
+	// EventTypeEventRaised is the event type for when an external event
+	// has been raised.
+	EventTypeEventRaised
+	// EventTypeContinueAsNew is the event type for when an orchestration
+	// has been continued as new.
+	EventTypeContinueAsNew
+	// EventTypeGenericEvent is the event type for generic events.
+	EventTypeGenericEvent
+	// EventTypeHistoryState is the event type for history state events.
+	EventTypeHistoryState
+)
+
+// HistoryEvent represents an event in the history of an orchestration.
+type HistoryEvent struct {
+	Timestamp       time.Time
+	FireAt          time.Time
+	Name            string
+	Input           string
+	Result          string
+	Reason          string
+	Details         string
+	EventType       EventType
+	EventID         int `json:"EventId"`
+	TaskScheduledID int `json:"TaskScheduledId"`
+	TimerID         int `json:"TimerId"`
+	IsPlayed        bool
+}
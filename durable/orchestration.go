@@ -0,0 +1,343 @@
+package durable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTaskPending is returned when awaiting a task that has not yet
+	// completed. The orchestrator function should return when it
+	// receives this error, it will be replayed when the task has completed.
+	ErrTaskPending = errors.New("task pending")
+)
+
+// TaskFailedError is returned when awaiting an activity that
+// has failed.
+type TaskFailedError struct {
+	Name    string
+	Reason  string
+	Details string
+}
+
+// Error returns the error message.
+func (e *TaskFailedError) Error() string {
+	if len(e.Details) > 0 {
+		return fmt.Sprintf("activity %s failed: %s: %s", e.Name, e.Reason, e.Details)
+	}
+	return fmt.Sprintf("activity %s failed: %s", e.Name, e.Reason)
+}
+
+// OrchestrationRequest represents the payload of an orchestration trigger.
+type OrchestrationRequest struct {
+	Input            json.RawMessage `json:"input"`
+	InstanceID       string          `json:"instanceId"`
+	ParentInstanceID string          `json:"parentInstanceId"`
+	History          []HistoryEvent  `json:"history"`
+	IsReplaying      bool            `json:"isReplaying"`
+}
+
+// OrchestrationContext is the context of an orchestration. It replays
+// the history of the orchestration and records the actions scheduled
+// by the orchestrator function.
+type OrchestrationContext struct {
+	currentUTCDateTime time.Time
+	output             any
+	customStatus       any
+	instanceID         string
+	parentInstanceID   string
+	input              json.RawMessage
+	history            []HistoryEvent
+	processed          []bool
+	actions            [][]Action
+	pendingActions     []Action
+	isReplaying        bool
+	blocked            bool
+	done               bool
+}
+
+// NewOrchestrationContext creates a new OrchestrationContext from the
+// provided request.
+func NewOrchestrationContext(req OrchestrationRequest) *OrchestrationContext {
+	c := &OrchestrationContext{
+		instanceID:       req.InstanceID,
+		parentInstanceID: req.ParentInstanceID,
+		input:            req.Input,
+		history:          req.History,
+		processed:        make([]bool, len(req.History)),
+		isReplaying:      req.IsReplaying,
+	}
+
+	for _, event := range req.History {
+		if event.EventType == EventTypeOrchestratorStarted && c.currentUTCDateTime.IsZero() {
+			c.currentUTCDateTime = event.Timestamp
+		}
+		if event.EventType == EventTypeExecutionStarted && (len(c.input) == 0 || string(c.input) == "null") && len(event.Input) > 0 {
+			c.input = json.RawMessage(event.Input)
+		}
+		if event.IsPlayed {
+			c.isReplaying = true
+		}
+	}
+
+	return c
+}
+
+// InstanceID returns the instance ID of the orchestration.
+func (c OrchestrationContext) InstanceID() string {
+	return c.instanceID
+}
+
+// ParentInstanceID returns the instance ID of the parent orchestration,
+// if the orchestration is a sub-orchestration.
+func (c OrchestrationContext) ParentInstanceID() string {
+	return c.parentInstanceID
+}
+
+// IsReplaying returns true if the orchestrator function is currently
+// replaying events from the history. Use it to avoid side effects, such
+// as logging, during replay.
+func (c OrchestrationContext) IsReplaying() bool {
+	return c.isReplaying
+}
+
+// CurrentUTCDateTime returns the current date and time of the
+// orchestration in a replay-safe manner.
+func (c OrchestrationContext) CurrentUTCDateTime() time.Time {
+	return c.currentUTCDateTime
+}
+
+// Input parses the input of the orchestration into the provided value.
+func (c OrchestrationContext) Input(v any) error {
+	if len(c.input) == 0 {
+		return nil
+	}
+	return json.Unmarshal(c.input, &v)
+}
+
+// SetOutput sets the output of the orchestration.
+func (c *OrchestrationContext) SetOutput(v any) {
+	c.output = v
+}
+
+// SetCustomStatus sets the custom status of the orchestration.
+func (c *OrchestrationContext) SetCustomStatus(v any) {
+	c.customStatus = v
+}
+
+// CallActivity schedules the activity with the provided name and
+// input. The returned task should be awaited for the result.
+func (c *OrchestrationContext) CallActivity(name string, input any) *Task {
+	if !c.schedule(Action{
+		ActionType:   ActionTypeCallActivity,
+		FunctionName: name,
+		Input:        input,
+	}) {
+		return &Task{c: c}
+	}
+
+	scheduled := c.find(func(e HistoryEvent) bool {
+		return e.EventType == EventTypeTaskScheduled && e.Name == name
+	})
+	if scheduled < 0 {
+		return &Task{c: c}
+	}
+
+	id := c.history[scheduled].EventID
+	completed := c.find(func(e HistoryEvent) bool {
+		return (e.EventType == EventTypeTaskCompleted || e.EventType == EventTypeTaskFailed) && e.TaskScheduledID == id
+	})
+	if completed < 0 {
+		return &Task{c: c}
+	}
+
+	event := c.history[completed]
+	task := &Task{c: c, index: completed, completed: true, played: event.IsPlayed}
+	if event.EventType == EventTypeTaskFailed {
+		task.err = &TaskFailedError{Name: name, Reason: event.Reason, Details: event.Details}
+	} else {
+		task.result = json.RawMessage(event.Result)
+	}
+	return task
+}
+
+// CreateTimer creates a durable timer that fires at the provided time.
+// The returned task should be awaited for the timer to fire.
+func (c *OrchestrationContext) CreateTimer(fireAt time.Time) *Task {
+	fireAt = fireAt.UTC()
+	if !c.schedule(Action{
+		ActionType: ActionTypeCreateTimer,
+		FireAt:     &fireAt,
+	}) {
+		return &Task{c: c}
+	}
+
+	created := c.find(func(e HistoryEvent) bool {
+		return e.EventType == EventTypeTimerCreated
+	})
+	if created < 0 {
+		return &Task{c: c}
+	}
+
+	id := c.history[created].EventID
+	fired := c.find(func(e HistoryEvent) bool {
+		return e.EventType == EventTypeTimerFired && e.TimerID == id
+	})
+	if fired < 0 {
+		return &Task{c: c}
+	}
+
+	return &Task{c: c, index: fired, completed: true, played: c.history[fired].IsPlayed}
+}
+
+// WaitForExternalEvent waits for an external event with the provided
+// name. The returned task should be awaited for the data of the event.
+func (c *OrchestrationContext) WaitForExternalEvent(name string) *Task {
+	if !c.schedule(Action{
+		ActionType:        ActionTypeWaitForExternalEvent,
+		ExternalEventName: name,
+		Reason:            "ExternalEvent",
+	}) {
+		return &Task{c: c}
+	}
+
+	raised := c.find(func(e HistoryEvent) bool {
+		return e.EventType == EventTypeEventRaised && strings.EqualFold(e.Name, name)
+	})
+	if raised < 0 {
+		return &Task{c: c}
+	}
+
+	event := c.history[raised]
+	return &Task{c: c, index: raised, completed: true, played: event.IsPlayed, result: json.RawMessage(event.Input)}
+}
+
+// ContinueAsNew restarts the orchestration with the provided input
+// and a cleared history. The orchestrator function should return
+// after calling it.
+func (c *OrchestrationContext) ContinueAsNew(input any) {
+	if c.schedule(Action{
+		ActionType: ActionTypeContinueAsNew,
+		Input:      input,
+	}) {
+		c.done = true
+	}
+}
+
+// Run runs the provided orchestrator function and returns the
+// resulting state to return to the durable extension.
+func (c *OrchestrationContext) Run(fn func() error) OrchestratorState {
+	err := fn()
+	c.flush()
+
+	state := OrchestratorState{
+		Actions:      c.actions,
+		CustomStatus: c.customStatus,
+	}
+	if state.Actions == nil {
+		state.Actions = [][]Action{}
+	}
+
+	switch {
+	case c.blocked:
+	case err != nil && !errors.Is(err, ErrTaskPending):
+		state.Error = err.Error()
+	default:
+		state.IsDone = true
+		state.Output = c.output
+	}
+	return state
+}
+
+// schedule records the provided action. It returns false if
+// the orchestration is blocked or done, and no further actions
+// should be scheduled.
+func (c *OrchestrationContext) schedule(action Action) bool {
+	if c.blocked || c.done {
+		return false
+	}
+	c.pendingActions = append(c.pendingActions, action)
+	return true
+}
+
+// flush moves the pending actions to the recorded actions.
+func (c *OrchestrationContext) flush() {
+	if len(c.pendingActions) == 0 {
+		return
+	}
+	c.actions = append(c.actions, c.pendingActions)
+	c.pendingActions = nil
+}
+
+// find returns the index of the first unprocessed history event matching
+// the provided function and marks it as processed. Returns -1 if no event
+// is found.
+func (c *OrchestrationContext) find(fn func(e HistoryEvent) bool) int {
+	for i, event := range c.history {
+		if c.processed[i] || !fn(event) {
+			continue
+		}
+		c.processed[i] = true
+		return i
+	}
+	return -1
+}
+
+// advance sets the current date and time to the timestamp of the orchestrator
+// started event of the execution in which the event at the provided index
+// was added to the history.
+func (c *OrchestrationContext) advance(index int) {
+	for i := index; i >= 0; i-- {
+		if c.history[i].EventType == EventTypeOrchestratorStarted {
+			if c.history[i].Timestamp.After(c.currentUTCDateTime) {
+				c.currentUTCDateTime = c.history[i].Timestamp
+			}
+			return
+		}
+	}
+}
+
+// Task represents a durable task scheduled by an orchestration.
+type Task struct {
+	c         *OrchestrationContext
+	err       error
+	result    json.RawMessage
+	index     int
+	completed bool
+	played    bool
+}
+
+// Await the task. If the task has completed, its result is parsed into
+// the provided value (if not nil). If the task has not completed,
+// ErrTaskPending is returned and the orchestrator function should return.
+func (t *Task) Await(v any) error {
+	c := t.c
+	if c.blocked {
+		return ErrTaskPending
+	}
+	c.flush()
+
+	if !t.completed {
+		c.blocked = true
+		c.isReplaying = false
+		return ErrTaskPending
+	}
+
+	c.isReplaying = t.played
+	c.advance(t.index)
+	if t.err != nil {
+		return t.err
+	}
+	if v == nil || len(t.result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(t.result, &v)
+}
+
+// Completed returns true if the task has completed.
+func (t Task) Completed() bool {
+	return t.completed
+}
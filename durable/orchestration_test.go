@@ -0,0 +1,253 @@
+package durable
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOrchestrationContext_Run(t *testing.T) {
+	helloSequence := func(c *OrchestrationContext) error {
+		var name string
+		if err := c.Input(&name); err != nil {
+			return err
+		}
+		var first, second string
+		if err := c.CallActivity("SayHello", name).Await(&first); err != nil {
+			return err
+		}
+		if err := c.CallActivity("SayHello", "London").Await(&second); err != nil {
+			return err
+		}
+		c.SetOutput([]string{first, second})
+		return nil
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			req OrchestrationRequest
+			fn  func(c *OrchestrationContext) error
+		}
+		want OrchestratorState
+	}{
+		{
+			name: "first execution",
+			input: struct {
+				req OrchestrationRequest
+				fn  func(c *OrchestrationContext) error
+			}{
+				req: OrchestrationRequest{
+					Input:   []byte(`"Tokyo"`),
+					History: _testHistoryStarted,
+				},
+				fn: helloSequence,
+			},
+			want: OrchestratorState{
+				Actions: [][]Action{
+					{{ActionType: ActionTypeCallActivity, FunctionName: "SayHello", Input: "Tokyo"}},
+				},
+			},
+		},
+		{
+			name: "replay with first activity completed",
+			input: struct {
+				req OrchestrationRequest
+				fn  func(c *OrchestrationContext) error
+			}{
+				req: OrchestrationRequest{
+					Input:   []byte(`"Tokyo"`),
+					History: concat(_testHistoryStarted, _testHistoryFirstCompleted),
+				},
+				fn: helloSequence,
+			},
+			want: OrchestratorState{
+				Actions: [][]Action{
+					{{ActionType: ActionTypeCallActivity, FunctionName: "SayHello", Input: "Tokyo"}},
+					{{ActionType: ActionTypeCallActivity, FunctionName: "SayHello", Input: "London"}},
+				},
+			},
+		},
+		{
+			name: "replay with all activities completed",
+			input: struct {
+				req OrchestrationRequest
+				fn  func(c *OrchestrationContext) error
+			}{
+				req: OrchestrationRequest{
+					Input:   []byte(`"Tokyo"`),
+					History: concat(_testHistoryStarted, _testHistoryFirstCompleted, _testHistorySecondCompleted),
+				},
+				fn: helloSequence,
+			},
+			want: OrchestratorState{
+				Actions: [][]Action{
+					{{ActionType: ActionTypeCallActivity, FunctionName: "SayHello", Input: "Tokyo"}},
+					{{ActionType: ActionTypeCallActivity, FunctionName: "SayHello", Input: "London"}},
+				},
+				Output: []string{"Hello Tokyo!", "Hello London!"},
+				IsDone: true,
+			},
+		},
+		{
+			name: "activity failed",
+			input: struct {
+				req OrchestrationRequest
+				fn  func(c *OrchestrationContext) error
+			}{
+				req: OrchestrationRequest{
+					Input:   []byte(`"Tokyo"`),
+					History: concat(_testHistoryStarted, _testHistoryFirstFailed),
+				},
+				fn: helloSequence,
+			},
+			want: OrchestratorState{
+				Actions: [][]Action{
+					{{ActionType: ActionTypeCallActivity, FunctionName: "SayHello", Input: "Tokyo"}},
+				},
+				Error: "activity SayHello failed: error",
+			},
+		},
+		{
+			name: "timer and external event",
+			input: struct {
+				req OrchestrationRequest
+				fn  func(c *OrchestrationContext) error
+			}{
+				req: OrchestrationRequest{
+					History: concat(_testHistoryStarted, _testHistoryTimerFired),
+				},
+				fn: func(c *OrchestrationContext) error {
+					if err := c.CreateTimer(c.CurrentUTCDateTime().Add(time.Minute)).Await(nil); err != nil {
+						return err
+					}
+					var approved bool
+					if err := c.WaitForExternalEvent("Approval").Await(&approved); err != nil {
+						return err
+					}
+					c.SetOutput(approved)
+					return nil
+				},
+			},
+			want: OrchestratorState{
+				Actions: [][]Action{
+					{{ActionType: ActionTypeCreateTimer, FireAt: &_testFireAt}},
+					{{ActionType: ActionTypeWaitForExternalEvent, ExternalEventName: "Approval", Reason: "ExternalEvent"}},
+				},
+			},
+		},
+		{
+			name: "continue as new",
+			input: struct {
+				req OrchestrationRequest
+				fn  func(c *OrchestrationContext) error
+			}{
+				req: OrchestrationRequest{
+					Input:   []byte(`1`),
+					History: _testHistoryStarted,
+				},
+				fn: func(c *OrchestrationContext) error {
+					var n int
+					if err := c.Input(&n); err != nil {
+						return err
+					}
+					c.ContinueAsNew(n + 1)
+					return nil
+				},
+			},
+			want: OrchestratorState{
+				Actions: [][]Action{
+					{{ActionType: ActionTypeContinueAsNew, Input: 2}},
+				},
+				IsDone: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewOrchestrationContext(test.input.req)
+			got := c.Run(func() error {
+				return test.input.fn(c)
+			})
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Run() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTask_Await(t *testing.T) {
+	c := NewOrchestrationContext(OrchestrationRequest{
+		History: concat(_testHistoryStarted, _testHistoryFirstCompleted),
+	})
+
+	var got string
+	if err := c.CallActivity("SayHello", "Tokyo").Await(&got); err != nil {
+		t.Fatalf("Await() = unexpected error: %v", err)
+	}
+	if got != "Hello Tokyo!" {
+		t.Errorf("Await() = unexpected result, want: %s, got: %s", "Hello Tokyo!", got)
+	}
+	if !c.CurrentUTCDateTime().Equal(_testOrchestrationTime2) {
+		t.Errorf("CurrentUTCDateTime() = unexpected result, want: %v, got: %v", _testOrchestrationTime2, c.CurrentUTCDateTime())
+	}
+
+	if err := c.CallActivity("SayHello", "London").Await(nil); !errors.Is(err, ErrTaskPending) {
+		t.Errorf("Await() = unexpected error, want: %v, got: %v", ErrTaskPending, err)
+	}
+	if c.IsReplaying() {
+		t.Errorf("IsReplaying() = unexpected result, want: false, got: true")
+	}
+}
+
+func concat(events ...[]HistoryEvent) []HistoryEvent {
+	var history []HistoryEvent
+	for _, e := range events {
+		history = append(history, e...)
+	}
+	return history
+}
+
+var (
+	_testOrchestrationTime1 = time.Date(2023, 10, 12, 20, 13, 49, 0, time.UTC)
+	_testOrchestrationTime2 = time.Date(2023, 10, 12, 20, 13, 50, 0, time.UTC)
+	_testOrchestrationTime3 = time.Date(2023, 10, 12, 20, 13, 51, 0, time.UTC)
+	_testFireAt             = _testOrchestrationTime1.Add(time.Minute)
+)
+
+var _testHistoryStarted = []HistoryEvent{
+	{EventType: EventTypeOrchestratorStarted, EventID: -1, Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeExecutionStarted, EventID: -1, Timestamp: _testOrchestrationTime1, IsPlayed: true},
+}
+
+var _testHistoryFirstCompleted = []HistoryEvent{
+	{EventType: EventTypeTaskScheduled, EventID: 0, Name: "SayHello", Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeOrchestratorCompleted, EventID: -1, Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeOrchestratorStarted, EventID: -1, Timestamp: _testOrchestrationTime2},
+	{EventType: EventTypeTaskCompleted, EventID: -1, TaskScheduledID: 0, Result: `"Hello Tokyo!"`, Timestamp: _testOrchestrationTime2},
+}
+
+var _testHistorySecondCompleted = []HistoryEvent{
+	{EventType: EventTypeTaskScheduled, EventID: 1, Name: "SayHello", Timestamp: _testOrchestrationTime2},
+	{EventType: EventTypeOrchestratorCompleted, EventID: -1, Timestamp: _testOrchestrationTime2},
+	{EventType: EventTypeOrchestratorStarted, EventID: -1, Timestamp: _testOrchestrationTime3},
+	{EventType: EventTypeTaskCompleted, EventID: -1, TaskScheduledID: 1, Result: `"Hello London!"`, Timestamp: _testOrchestrationTime3},
+}
+
+var _testHistoryFirstFailed = []HistoryEvent{
+	{EventType: EventTypeTaskScheduled, EventID: 0, Name: "SayHello", Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeOrchestratorCompleted, EventID: -1, Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeOrchestratorStarted, EventID: -1, Timestamp: _testOrchestrationTime2},
+	{EventType: EventTypeTaskFailed, EventID: -1, TaskScheduledID: 0, Reason: "error", Timestamp: _testOrchestrationTime2},
+}
+
+var _testHistoryTimerFired = []HistoryEvent{
+	{EventType: EventTypeTimerCreated, EventID: 0, FireAt: _testFireAt, Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeOrchestratorCompleted, EventID: -1, Timestamp: _testOrchestrationTime1, IsPlayed: true},
+	{EventType: EventTypeOrchestratorStarted, EventID: -1, Timestamp: _testFireAt},
+	{EventType: EventTypeTimerFired, EventID: -1, TimerID: 0, FireAt: _testFireAt, Timestamp: _testFireAt},
+}
@@ -0,0 +1,188 @@
+package azfunc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	// contentTypeProblemJSON is the content type of RFC 7807 problem
+	// details.
+	contentTypeProblemJSON = "application/problem+json"
+)
+
+// Error is an error returned by a function that determines the response
+// to the function host. It is created with BadRequest, Transient or
+// Permanent, or directly for other status codes.
+type Error struct {
+	// Err is the underlying error.
+	Err error
+	// StatusCode is the status code of the response to HTTP triggers.
+	// Defaults to 500.
+	StatusCode int
+	// Transient reports if the error is transient, and the invocation
+	// should be retried. Invocations of other triggers than HTTP that fail
+	// with an error that is not transient are skipped (completed), since
+	// retrying them would fail again.
+	Transient bool
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.statusCode())
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// statusCode returns the status code of the error, or 500 if it has
+// not been set.
+func (e *Error) statusCode() int {
+	if e.StatusCode < http.StatusContinue || e.StatusCode > http.StatusNetworkAuthenticationRequired {
+		return http.StatusInternalServerError
+	}
+	return e.StatusCode
+}
+
+// BadRequest returns an error for invalid input. HTTP triggers respond
+// with 400 and the message of the error. Invocations of other triggers
+// are skipped.
+func BadRequest(err error) error {
+	return &Error{Err: err, StatusCode: http.StatusBadRequest}
+}
+
+// Transient returns an error for a failure that can succeed if retried,
+// such as an unavailable dependency. HTTP triggers respond with 503.
+// Invocations of other triggers fail, for the function host to retry them.
+// It is retried by WithRetry.
+func Transient(err error) error {
+	return &Error{Err: err, StatusCode: http.StatusServiceUnavailable, Transient: true}
+}
+
+// Permanent returns an error for a failure that will not succeed if
+// retried. HTTP triggers respond with 500. Invocations of other triggers
+// are skipped. It is not retried by WithRetry.
+func Permanent(err error) error {
+	return &Error{Err: err, StatusCode: http.StatusInternalServerError}
+}
+
+// IsTransient returns true if the provided error is a transient *Error.
+func IsTransient(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Transient
+}
+
+// IsPermanent returns true if the provided error is an *Error that is not
+// transient (created with BadRequest or Permanent).
+func IsPermanent(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && !e.Transient
+}
+
+// ErrorHandler is a function that writes the response to the function host
+// when a function returns an error.
+type ErrorHandler func(ctx *Context, info FunctionInfo, w http.ResponseWriter, err error)
+
+// WithErrorHandler sets the provided handler to write the response to the
+// function host when a function returns an error. Defaults to
+// DefaultErrorHandler.
+func WithErrorHandler(h ErrorHandler) FunctionAppOption {
+	return func(a *functionApp) {
+		if h != nil {
+			a.errorHandler = h
+		}
+	}
+}
+
+// Problem contains problem details (RFC 7807).
+type Problem struct {
+	// Type is a URI that identifies the problem type.
+	Type string `json:"type"`
+	// Title is a short summary of the problem type.
+	Title string `json:"title"`
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+	// Detail is an explanation of the occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance identifies the occurrence of the problem (the invocation
+	// ID).
+	Instance string `json:"instance,omitempty"`
+}
+
+// DefaultErrorHandler is the default ErrorHandler. The error is logged to
+// the logger and the invocation logs (panics have already been logged).
+//   - HTTP triggers: The response of the HTTP output binding is set to the
+//     status code of the error with problem details (RFC 7807) as body.
+//     The message of the error is only included for client errors (4xx),
+//     to not expose internal errors to callers. Other output bindings are
+//     not sent. For functions registered with HTTPHandler, the problem
+//     details are written as the response.
+//   - Other triggers: Permanent errors (BadRequest and Permanent) skip the
+//     invocation, which completes it without output bindings. Other errors
+//     fail the invocation, for the function host to retry it.
+func DefaultErrorHandler(ctx *Context, info FunctionInfo, w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	var e *Error
+	if errors.As(err, &e) {
+		statusCode = e.statusCode()
+	}
+
+	var perr *PanicError
+	if !errors.As(err, &perr) {
+		if statusCode >= http.StatusInternalServerError {
+			ctx.Log().Error(err.Error())
+			ctx.Outputs.Log().Error(err.Error())
+		} else {
+			ctx.Log().Warn(err.Error())
+			ctx.Outputs.Log().Warn(err.Error())
+		}
+	}
+
+	if info.TriggerType == "httpTrigger" {
+		problem := Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(statusCode),
+			Status:   statusCode,
+			Instance: ctx.InvocationID(),
+		}
+		if statusCode < http.StatusInternalServerError {
+			problem.Detail = err.Error()
+		}
+		b, _ := json.Marshal(problem)
+		if ctx.invocation.forwarded {
+			w.Header().Set("Content-Type", contentTypeProblemJSON)
+			w.WriteHeader(statusCode)
+			w.Write(b)
+			return
+		}
+		res := ctx.Outputs.HTTP()
+		res.Header().Set("Content-Type", contentTypeProblemJSON)
+		res.WriteHeader(statusCode)
+		res.Write(b)
+		o := &outputs{log: ctx.Outputs.log}
+		o.Add(res)
+		writeOutputs(w, http.StatusOK, o)
+		return
+	}
+
+	if e != nil && !e.Transient {
+		ctx.Outputs.Log().Warn("Invocation skipped.")
+		writeOutputs(w, http.StatusOK, &outputs{log: ctx.Outputs.log})
+		return
+	}
+	writeOutputs(w, http.StatusInternalServerError, ctx.Outputs)
+}
+
+// writeOutputs writes the provided outputs as the response to the function
+// host, with the provided status code.
+func writeOutputs(w http.ResponseWriter, statusCode int, o *outputs) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(o.json())
+}
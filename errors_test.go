@@ -0,0 +1,341 @@
+package azfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsTransient_IsPermanent(t *testing.T) {
+	var tests = []struct {
+		name          string
+		input         error
+		wantTransient bool
+		wantPermanent bool
+	}{
+		{
+			name:  "error",
+			input: errTestFunction,
+		},
+		{
+			name:          "transient",
+			input:         fmt.Errorf("wrapped: %w", Transient(errTestFunction)),
+			wantTransient: true,
+		},
+		{
+			name:          "permanent",
+			input:         Permanent(errTestFunction),
+			wantPermanent: true,
+		},
+		{
+			name:          "bad request",
+			input:         BadRequest(errTestFunction),
+			wantPermanent: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsTransient(test.input); got != test.wantTransient {
+				t.Errorf("IsTransient() = unexpected result, want: %v, got: %v\n", test.wantTransient, got)
+			}
+			if got := IsPermanent(test.input); got != test.wantPermanent {
+				t.Errorf("IsPermanent() = unexpected result, want: %v, got: %v\n", test.wantPermanent, got)
+			}
+			if !errors.Is(test.input, errTestFunction) {
+				t.Errorf("Error = expected to wrap: %v\n", errTestFunction)
+			}
+		})
+	}
+}
+
+func TestDefaultErrorHandler_HTTP(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input error
+		want  Problem
+	}{
+		{
+			name:  "error",
+			input: errTestFunction,
+			want: Problem{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Instance: "1234",
+			},
+		},
+		{
+			name:  "bad request",
+			input: BadRequest(errTestFunction),
+			want: Problem{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   errTestFunction.Error(),
+				Instance: "1234",
+			},
+		},
+		{
+			name:  "transient",
+			input: Transient(errTestFunction),
+			want: Problem{
+				Type:     "about:blank",
+				Title:    "Service Unavailable",
+				Status:   http.StatusServiceUnavailable,
+				Instance: "1234",
+			},
+		},
+		{
+			name:  "status code",
+			input: &Error{Err: errTestFunction, StatusCode: http.StatusNotFound},
+			want: Problem{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   errTestFunction.Error(),
+				Instance: "1234",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello-http", HTTPTrigger(func(ctx *Context, trigger *trigger.HTTP) error {
+				ctx.Outputs.HTTP().Header().Set("X-Custom", "value")
+				return test.input
+			}), WithOutput(output.NewHTTP()))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello-http", bytes.NewReader([]byte(`{"Data":{"req":{"Method":"GET","Headers":{}}},"Metadata":{}}`)))
+			r.Header.Set(headerInvocationID, "1234")
+			app.handler(app.functions["hello-http"]).ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("DefaultErrorHandler() = unexpected status code, want: %d, got: %d\n", http.StatusOK, w.Code)
+			}
+
+			var res struct {
+				Outputs struct {
+					Res struct {
+						StatusCode string            `json:"statusCode"`
+						Headers    map[string]string `json:"headers"`
+						Body       string            `json:"body"`
+					} `json:"res"`
+				}
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("DefaultErrorHandler() = unexpected error: %v", err)
+			}
+			var got Problem
+			if err := json.Unmarshal([]byte(res.Outputs.Res.Body), &got); err != nil {
+				t.Fatalf("DefaultErrorHandler() = unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("DefaultErrorHandler() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(fmt.Sprint(test.want.Status), res.Outputs.Res.StatusCode); diff != "" {
+				t.Errorf("DefaultErrorHandler() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+			wantHeaders := map[string]string{"Content-Type": contentTypeProblemJSON, "X-Custom": "value"}
+			if diff := cmp.Diff(wantHeaders, res.Outputs.Res.Headers); diff != "" {
+				t.Errorf("DefaultErrorHandler() = unexpected headers (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultErrorHandler_HTTP_Outputs(t *testing.T) {
+	app := NewFunctionApp(WithDisableLogging())
+	app.AddFunction("hello-http", HTTPTrigger(func(ctx *Context, trigger *trigger.HTTP) error {
+		ctx.Outputs.Binding("queue").Write([]byte(`{"message":"hello"}`))
+		ctx.Outputs.Log().Info("hello")
+		return errTestFunction
+	}), WithOutput(output.NewHTTP()), WithOutput(output.NewQueue("queue")))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hello-http", bytes.NewReader([]byte(`{"Data":{"req":{"Method":"GET","Headers":{}}},"Metadata":{}}`)))
+	app.handler(app.functions["hello-http"]).ServeHTTP(w, r)
+
+	var res struct {
+		Outputs map[string]json.RawMessage
+		Logs    []string
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("DefaultErrorHandler() = unexpected error: %v", err)
+	}
+
+	var got []string
+	for name := range res.Outputs {
+		got = append(got, name)
+	}
+	if diff := cmp.Diff([]string{"res"}, got); diff != "" {
+		t.Errorf("DefaultErrorHandler() = unexpected outputs (-want +got)\n%s\n", diff)
+	}
+	if len(res.Logs) != 2 {
+		t.Errorf("DefaultErrorHandler() = unexpected number of logs, want: 2, got: %d\n", len(res.Logs))
+	}
+}
+
+func TestDefaultErrorHandler_LogAttrs(t *testing.T) {
+	var tests = []struct {
+		name string
+		fn   QueueTriggerFunc
+	}{
+		{
+			name: "error",
+			fn: func(ctx *Context, trigger *trigger.Queue) error {
+				return errTestFunction
+			},
+		},
+		{
+			name: "panic",
+			fn: func(ctx *Context, trigger *trigger.Queue) error {
+				panic("panic")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello-queue", QueueTrigger("queue", test.fn))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+			app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+			var res struct {
+				Logs []string
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("DefaultErrorHandler() = unexpected error: %v", err)
+			}
+			if len(res.Logs) == 0 {
+				t.Fatalf("DefaultErrorHandler() = expected invocation logs\n")
+			}
+			for _, l := range res.Logs {
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(l), &entry); err != nil {
+					t.Fatalf("DefaultErrorHandler() = unexpected error: %v", err)
+				}
+				if diff := cmp.Diff("hello-queue", entry["functionName"]); diff != "" {
+					t.Errorf("DefaultErrorHandler() = unexpected function name (-want +got)\n%s\n", diff)
+				}
+				if _, ok := entry["function"]; ok {
+					t.Errorf("DefaultErrorHandler() = unexpected attribute function\n")
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultErrorHandler_Queue(t *testing.T) {
+	var tests = []struct {
+		name        string
+		input       error
+		wantStatus  int
+		wantOutputs map[string]string
+		wantSkipped bool
+	}{
+		{
+			name:        "error fails",
+			input:       errTestFunction,
+			wantStatus:  http.StatusInternalServerError,
+			wantOutputs: map[string]string{"queue": "partial"},
+		},
+		{
+			name:        "transient fails",
+			input:       Transient(errTestFunction),
+			wantStatus:  http.StatusInternalServerError,
+			wantOutputs: map[string]string{"queue": "partial"},
+		},
+		{
+			name:        "permanent skips",
+			input:       Permanent(errTestFunction),
+			wantStatus:  http.StatusOK,
+			wantOutputs: map[string]string{},
+			wantSkipped: true,
+		},
+		{
+			name:        "bad request skips",
+			input:       BadRequest(errTestFunction),
+			wantStatus:  http.StatusOK,
+			wantOutputs: map[string]string{},
+			wantSkipped: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				ctx.Outputs.Binding("queue").Write([]byte("partial"))
+				return test.input
+			}))
+
+			w := httptest.NewRecorder()
+			app.handler(app.functions["hello-queue"]).ServeHTTP(w, newQueueRequest())
+
+			if diff := cmp.Diff(test.wantStatus, w.Code); diff != "" {
+				t.Errorf("DefaultErrorHandler() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			var res struct {
+				Outputs map[string]string
+				Logs    []string
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("DefaultErrorHandler() = unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.wantOutputs, res.Outputs); diff != "" {
+				t.Errorf("DefaultErrorHandler() = unexpected outputs (-want +got)\n%s\n", diff)
+			}
+			if len(res.Logs) == 0 || !strings.Contains(res.Logs[0], errTestFunction.Error()) {
+				t.Errorf("DefaultErrorHandler() = expected error in logs, got: %v\n", res.Logs)
+			}
+			skipped := strings.Contains(strings.Join(res.Logs, "\n"), "Invocation skipped.")
+			if test.wantSkipped != skipped {
+				t.Errorf("DefaultErrorHandler() = unexpected skipped, want: %v, got: %v\n", test.wantSkipped, skipped)
+			}
+		})
+	}
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var gotErr error
+	var gotInfo FunctionInfo
+	app := NewFunctionApp(WithDisableLogging(), WithErrorHandler(func(ctx *Context, info FunctionInfo, w http.ResponseWriter, err error) {
+		gotErr, gotInfo = err, info
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		return errTestFunction
+	}))
+
+	w := httptest.NewRecorder()
+	app.handler(app.functions["hello-queue"]).ServeHTTP(w, newQueueRequest())
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("WithErrorHandler() = unexpected status code, want: %d, got: %d\n", http.StatusTeapot, w.Code)
+	}
+	if !errors.Is(gotErr, errTestFunction) {
+		t.Errorf("WithErrorHandler() = unexpected error, want: %v, got: %v\n", errTestFunction, gotErr)
+	}
+	if gotInfo.Name != "hello-queue" {
+		t.Errorf("WithErrorHandler() = unexpected function name, want: hello-queue, got: %s\n", gotInfo.Name)
+	}
+}
+
+var errTestFunction = errors.New("function error")
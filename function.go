@@ -2,12 +2,20 @@ package azfunc
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/KarlGW/azfunc/durable"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/KarlGW/azfunc/uuid"
 )
 
 const (
@@ -22,6 +30,12 @@ const (
 	functionsDisableLogging = "FUNCTIONS_DISABLE_LOGGING"
 )
 
+const (
+	// headerInvocationID is the header that contains the ID of the
+	// invocation, set by the function host.
+	headerInvocationID = "X-Azure-Functions-InvocationId"
+)
+
 const (
 	// defaultReadTimeout is the default read timeout for the
 	// function app's HTTP server.
@@ -34,9 +48,14 @@ const (
 	defaultIdleTimeout = 60 * time.Second
 )
 
-// ErrNoFunction is returned when no function has been set to the
-// FunctionApp.
-var ErrNoFunction = errors.New("at least one function must be set")
+var (
+	// ErrNoFunction is returned when no function has been set to the
+	// FunctionApp.
+	ErrNoFunction = errors.New("at least one function must be set")
+	// ErrDurableClientNotFound is returned when the durable client binding
+	// is not found in the request.
+	ErrDurableClientNotFound = errors.New("durable client binding not found")
+)
 
 // function is an internal structure that represents a function
 // in a FunctionApp.
@@ -44,6 +63,26 @@ type function struct {
 	name    string
 	trigger triggerable
 	outputs []outputable
+	// durableClient contains the name of the durable client binding,
+	// if any.
+	durableClient string
+	// durableClientOptions contains the options of the durable client.
+	durableClientOptions []durable.ClientOption
+	// middleware contains the middleware of the function.
+	middleware []Middleware
+	// timeout is the timeout of the function. Overrides the
+	// function timeout of the FunctionApp.
+	timeout time.Duration
+	// dependencies contains the dependencies required by the function.
+	dependencies []dependencyKey
+	// concurrency contains the concurrency limit of the function.
+	// Overrides the concurrency limit of the FunctionApp.
+	concurrency *concurrencyLimit
+	// retry contains the retry policy of the function. Not retried
+	// if nil.
+	retry *retryPolicy
+	// poison contains the poison output of the function, if any.
+	poison *poisonPolicy
 }
 
 // FunctionOption sets options to the function.
@@ -60,6 +99,26 @@ func WithOutput(output outputable) FunctionOption {
 	}
 }
 
+// WithDurableClient sets the durable client binding with the provided
+// name to the function. The client is available with ctx.DurableClient().
+// Options (e.g. the HTTP client used to call the management API) are
+// applied to the client of every invocation.
+func WithDurableClient(name string, options ...durable.ClientOption) FunctionOption {
+	return func(f *function) {
+		f.durableClient = name
+		f.durableClientOptions = options
+	}
+}
+
+// WithFunctionTimeout sets the timeout of the function. The Context of
+// the function is cancelled when the timeout has elapsed. Overrides the
+// functionTimeout of host.json.
+func WithFunctionTimeout(d time.Duration) FunctionOption {
+	return func(f *function) {
+		f.timeout = d
+	}
+}
+
 // functionApp represents a Function App with its configuration
 // and functions.
 type functionApp struct {
@@ -78,9 +137,59 @@ type functionApp struct {
 	log    Logger
 	stopCh chan os.Signal
 	errCh  chan error
+	// startHooks contains the hooks run when the FunctionApp is started.
+	startHooks []Hook
+	// stopHooks contains the hooks run when the FunctionApp is stopped.
+	stopHooks []Hook
 	// shutdownFuncs contains functions that will be called when the
-	// FunctionApp is stopped.
+	// FunctionApp is stopped, after the stop hooks.
 	shutdownFuncs []func() error
+	// shutdownTimeout is the time to wait for in-flight invocations to
+	// finish when the FunctionApp is stopped.
+	shutdownTimeout time.Duration
+	// draining is set when the FunctionApp is stopping and waits for
+	// in-flight invocations to finish.
+	draining *atomic.Bool
+	// inFlight tracks the invocations in flight, so that the stop hooks
+	// are run after they have returned.
+	inFlight *sync.WaitGroup
+	// middleware contains the middleware run for all functions.
+	middleware []Middleware
+	// panicHook is called when a function panics.
+	panicHook PanicHook
+	// functionTimeout is the timeout of all functions. Read from
+	// functionTimeout in host.json if not set.
+	functionTimeout time.Duration
+	// ctx is the context of the FunctionApp. It is cancelled when
+	// the FunctionApp is stopped, which cancels the Context of
+	// in-flight invocations.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// validate sets if the function.json of the functions should be
+	// validated when the FunctionApp is started.
+	validate bool
+	// configDir contains the directory with host.json and the function.json
+	// of the functions. Defaults to the directory of the executable.
+	configDir string
+	// tracer starts the span of every invocation. Tracing is disabled
+	// if nil.
+	tracer Tracer
+	// metrics contains the invocation metrics of the functions. Metrics
+	// are disabled if nil.
+	metrics *metrics
+	// adminServer serves the admin endpoints. Disabled if nil.
+	adminServer *http.Server
+	// readinessChecks contains the checks run on the readiness endpoint
+	// of the admin server.
+	readinessChecks []readinessCheck
+	// registry contains the dependencies provided to the FunctionApp.
+	registry *registry
+	// concurrency contains the default concurrency limit of the
+	// functions. Unlimited if nil.
+	concurrency *concurrencyLimit
+	// errorHandler writes the response to the function host when a
+	// function returns an error.
+	errorHandler ErrorHandler
 }
 
 // FunctionAppOption is a function that sets options to a
@@ -95,7 +204,12 @@ func NewFunctionApp(options ...FunctionAppOption) *functionApp {
 	}
 
 	router := http.NewServeMux()
+	ctx, cancel := context.WithCancel(context.Background())
 	app := &functionApp{
+		ctx:      ctx,
+		cancel:   cancel,
+		draining: &atomic.Bool{},
+		inFlight: &sync.WaitGroup{},
 		httpServer: &http.Server{
 			Addr:         os.Getenv(functionsCustomHandlerHost) + ":" + port,
 			Handler:      router,
@@ -103,16 +217,23 @@ func NewFunctionApp(options ...FunctionAppOption) *functionApp {
 			WriteTimeout: defaultWriteTimeout,
 			IdleTimeout:  defaultIdleTimeout,
 		},
-		functions: make(map[string]function),
-		router:    router,
-		log:       setupLogger(),
-		stopCh:    make(chan os.Signal),
-		errCh:     make(chan error),
+		functions:    make(map[string]function),
+		router:       router,
+		log:          setupLogger(),
+		stopCh:       make(chan os.Signal),
+		errCh:        make(chan error),
+		errorHandler: DefaultErrorHandler,
 	}
 
 	for _, option := range options {
 		option(app)
 	}
+	if len(app.configDir) == 0 {
+		app.configDir = defaultConfigDir()
+	}
+	if app.registry != nil {
+		app.registry.log = app.log
+	}
 
 	return app
 }
@@ -124,8 +245,8 @@ func New(options ...FunctionAppOption) *functionApp {
 
 // Start the FunctionApp.
 func (a functionApp) Start() error {
-	if len(a.functions) == 0 {
-		return ErrNoFunction
+	if err := a.setup(); err != nil {
+		return err
 	}
 	if a.stopCh == nil {
 		a.stopCh = make(chan os.Signal)
@@ -133,9 +254,8 @@ func (a functionApp) Start() error {
 	if a.errCh == nil {
 		a.errCh = make(chan error)
 	}
-
-	for name, function := range a.functions {
-		a.router.Handle("/"+name, a.handler(function))
+	if err := a.start(); err != nil {
+		return err
 	}
 
 	go func() {
@@ -145,6 +265,16 @@ func (a functionApp) Start() error {
 		}
 	}()
 
+	if a.adminServer != nil {
+		a.adminServer.Handler = a.adminHandler()
+		go func() {
+			if err := a.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				a.errCh <- err
+				return
+			}
+		}()
+	}
+
 	go func() {
 		a.stop()
 	}()
@@ -163,24 +293,87 @@ func (a functionApp) Start() error {
 	}
 }
 
+// setup validates the FunctionApp, reads the settings of host.json and
+// registers the handlers of the functions on its router.
+func (a *functionApp) setup() error {
+	if len(a.functions) == 0 {
+		return ErrNoFunction
+	}
+	if a.validate {
+		if err := a.Validate(a.configDir); err != nil {
+			return err
+		}
+	}
+	if err := a.checkDependencies(); err != nil {
+		return err
+	}
+	// host.json is only required to be valid if validation is enabled,
+	// otherwise the defaults are used.
+	if a.functionTimeout == 0 {
+		timeout, err := readFunctionTimeout(filepath.Join(a.configDir, "host.json"))
+		if err != nil {
+			if a.validate {
+				return err
+			}
+			a.log.Warn("Could not read functionTimeout from host.json, running without timeout.", "error", err.Error())
+		}
+		a.functionTimeout = timeout
+	}
+
+	handlers, err := a.httpHandlers()
+	if err != nil {
+		return err
+	}
+	var router *httpRouter
+	if handlers {
+		config, err := readHostHTTPConfig(filepath.Join(a.configDir, "host.json"))
+		if err != nil {
+			if a.validate {
+				return err
+			}
+			a.log.Warn("Could not read HTTP settings from host.json, using the defaults.", "error", err.Error())
+			config = hostHTTPConfig{routePrefix: defaultRoutePrefix, enableForwardingHTTPRequest: true}
+		}
+		if !config.enableForwardingHTTPRequest {
+			return ErrHTTPHandlerNotForwarded
+		}
+		router = newHTTPRouter(config.routePrefix)
+	}
+	return a.routes(router)
+}
+
+// routes registers the handlers of the functions on the router of the
+// FunctionApp. Functions registered with HTTPHandler are added to the
+// provided httpRouter, which is served on the route prefix.
+func (a functionApp) routes(router *httpRouter) error {
+	for name, function := range a.functions {
+		if t, ok := function.trigger.(httpHandlerTrigger); ok && router != nil {
+			if err := router.add(name, t.route(name), a.forwardedHandler(function, t)); err != nil {
+				return err
+			}
+			if router.pattern() == "/" {
+				// The function name route would shadow the route of
+				// the handler.
+				continue
+			}
+		}
+		a.router.Handle("/"+name, a.handler(function))
+	}
+	if router != nil {
+		a.router.Handle(router.pattern(), router)
+	}
+	return nil
+}
+
 // stop the FunctionApp.
 func (a functionApp) stop() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-stop
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-
-	a.httpServer.SetKeepAlivesEnabled(false)
-	if err := a.httpServer.Shutdown(ctx); err != nil {
+	if err := a.shutdown(); err != nil {
 		a.errCh <- err
-	}
-
-	for _, fn := range a.shutdownFuncs {
-		if err := fn(); err != nil {
-			a.errCh <- err
-		}
+		return
 	}
 
 	a.stopCh <- sig
@@ -212,25 +405,171 @@ func (a *functionApp) Register(name string, options ...FunctionOption) {
 
 // handler takes the provided function, creates a *Context and a trigger
 // and executes the function on the route it has been configured
-// with (the function name).
+// with (the function name). The number of concurrent invocations is
+// limited if the function or the FunctionApp has a concurrency limit.
 func (a functionApp) handler(fn function) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := newContext(context.Background(), func(o *contextOptions) {
-			o.outputs = newOutputs(withOutputs(fn.outputs...))
+	info := fn.info()
+	middleware := append(append([]Middleware{}, a.middleware...), fn.middleware...)
+
+	return a.inFlightHandler(a.concurrencyHandler(fn, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var durableClient *durable.Client
+		if len(fn.durableClient) > 0 {
+			var err error
+			durableClient, err = newDurableClient(r, fn.durableClient, fn.durableClientOptions...)
+			if err != nil {
+				a.log.Error(err.Error())
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var body []byte
+		if fn.poison != nil {
+			var err error
+			body, err = readBody(r)
+			if err != nil {
+				a.log.Error(err.Error())
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		reqCtx, cancel := a.invocationContext(r.Context(), fn)
+		defer cancel()
+
+		inv := newInvocation(r, info)
+		var span Span
+		if a.tracer != nil {
+			carrier, err := traceCarrier(r, info)
+			if err != nil {
+				a.log.Warn("Could not extract trace context.", "error", err.Error())
+			}
+			reqCtx, span = a.tracer.Start(reqCtx, carrier, inv.spanInfo(info))
+		}
+
+		ctx := newContext(reqCtx, func(o *contextOptions) {
+			o.outputs = newOutputs(withOutputs(cloneOutputs(fn.outputs...)...))
 			o.log = a.log
 			o.services = a.services
 			o.clients = a.clients
+			o.durableClient = durableClient
+			o.invocation = inv
+			o.tracer = a.tracer
+			o.registry = a.registry
+			o.request = r
 		})
-
-		if err := fn.trigger.run(ctx, r); err != nil {
-			a.log.Error(err.Error())
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		defer func() {
+			if err := ctx.scope.close(); err != nil {
+				a.log.Error(err.Error())
+			}
+		}()
+
+		run := a.recoverer(info, chain(info, a.retrier(fn, r, func(ctx *Context) error {
+			return fn.trigger.run(ctx, r)
+		}), middleware...))
+
+		err := run(ctx)
+		if span != nil {
+			span.End(err)
+		}
+		if a.metrics != nil {
+			a.metrics.record(info, time.Since(inv.startTime), r.ContentLength, err != nil)
+		}
+		if err != nil {
+			if fn.poison != nil {
+				if o, ok := fn.poison.route(ctx, info, body, err); ok {
+					writeOutputs(w, http.StatusOK, o)
+					return
+				}
+			}
+			errorHandler := a.errorHandler
+			if errorHandler == nil {
+				errorHandler = DefaultErrorHandler
+			}
+			errorHandler(ctx, info, w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(ctx.Outputs.json())
-	})
+	})))
+}
+
+// invocationContext derives the context of an invocation from the provided
+// context. It is cancelled when the FunctionApp is stopped, and has a deadline
+// if the function or the FunctionApp has a timeout.
+func (a functionApp) invocationContext(parent context.Context, fn function) (context.Context, context.CancelFunc) {
+	timeout := a.functionTimeout
+	if fn.timeout > 0 {
+		timeout = fn.timeout
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	if a.ctx == nil {
+		return ctx, cancel
+	}
+
+	stop := context.AfterFunc(a.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// newInvocation creates the metadata of an invocation from the provided
+// request and FunctionInfo. If the function host has not set an invocation
+// ID, a new one is generated.
+func newInvocation(r *http.Request, info FunctionInfo) invocation {
+	id := r.Header.Get(headerInvocationID)
+	if len(id) == 0 {
+		id, _ = uuid.New()
+	}
+	return invocation{
+		id:           id,
+		functionName: info.Name,
+		triggerType:  info.TriggerType,
+		startTime:    time.Now().UTC(),
+	}
+}
+
+// newDurableClient creates a durable client from the binding with the provided
+// name in the request, with the provided options. The body of the request
+// is restored to be read by the trigger.
+func newDurableClient(r *http.Request, name string, options ...durable.ClientOption) (*durable.Client, error) {
+	b, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Data map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return nil, trigger.ErrTriggerPayloadMalformed
+	}
+	d, ok := payload.Data[name]
+	if !ok {
+		return nil, ErrDurableClientNotFound
+	}
+
+	var binding durable.ClientBinding
+	if len(d) > 0 && d[0] == '"' {
+		var s string
+		if err := json.Unmarshal(d, &s); err != nil {
+			return nil, trigger.ErrTriggerPayloadMalformed
+		}
+		d = json.RawMessage(s)
+	}
+	if err := json.Unmarshal(d, &binding); err != nil {
+		return nil, trigger.ErrTriggerPayloadMalformed
+	}
+	return durable.NewClient(binding, options...), nil
 }
 
 // WithReadTimeout sets the read timeout for the FunctionApp
@@ -295,10 +634,25 @@ func WithDisableLogging() FunctionAppOption {
 	}
 }
 
+// WithValidation sets the FunctionApp to validate the function.json of the
+// registered functions in the provided directory when started. If dir is
+// empty, the directory of the executable is used. host.json is read from
+// the same directory.
+func WithValidation(dir string) FunctionAppOption {
+	return func(a *functionApp) {
+		a.validate = true
+		if len(dir) > 0 {
+			a.configDir = dir
+		}
+	}
+}
+
 // WithShutdownFunc sets a function that will be called when the
 // FunctionApp is stopped. This can be used to perform
 // cleanup operations or to gracefully shutdown dependencies.
-// Can be used multiple times to add multiple shutdown functions.
+// Can be used multiple times to add multiple shutdown functions,
+// which are called in the order they were added, after the stop
+// hooks (see OnStop).
 func WithShutdownFunc(fn func() error) FunctionAppOption {
 	return func(f *functionApp) {
 		if fn != nil {
@@ -343,6 +697,16 @@ func (c clients) Get(name string) any {
 	return c[name]
 }
 
+// defaultConfigDir returns the directory of the executable. If it cannot
+// be determined, the current working directory is used.
+func defaultConfigDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+	return filepath.Dir(exe)
+}
+
 // parseBool parses a string to a boolean.
 // Everything but "true" and "1" will return false.
 func parseBool(s string) bool {
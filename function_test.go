@@ -1,9 +1,19 @@
 package azfunc
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/KarlGW/azfunc/durable"
+	"github.com/KarlGW/azfunc/trigger"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -104,3 +114,281 @@ func TestWithDisableLogging(t *testing.T) {
 		t.Errorf("WithDisableLogging() = unexpected result (-want +got)\n%s\n", diff)
 	}
 }
+
+func TestNewFunctionApp_ConfigDir(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable() = unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		name  string
+		input []FunctionAppOption
+		want  string
+	}{
+		{
+			name: "default",
+			want: filepath.Dir(exe),
+		},
+		{
+			name:  "validation with empty dir",
+			input: []FunctionAppOption{WithValidation("")},
+			want:  filepath.Dir(exe),
+		},
+		{
+			name:  "validation with dir",
+			input: []FunctionAppOption{WithValidation("config")},
+			want:  "config",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewFunctionApp(test.input...).configDir
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewFunctionApp() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_Setup_HostConfig(t *testing.T) {
+	var tests = []struct {
+		name        string
+		validate    bool
+		hostJSON    []byte
+		wantTimeout time.Duration
+		wantErr     error
+	}{
+		{
+			name:        "valid",
+			hostJSON:    []byte(`{"version":"2.0","functionTimeout":"00:10:00","customHandler":{"enableForwardingHttpRequest":true}}`),
+			wantTimeout: 10 * time.Minute,
+		},
+		{
+			name:     "malformed",
+			hostJSON: []byte(`{"version":`),
+		},
+		{
+			name:     "invalid function timeout",
+			hostJSON: []byte(`{"version":"2.0","functionTimeout":"10m","customHandler":{"enableForwardingHttpRequest":true}}`),
+		},
+		{
+			name:     "malformed with validation",
+			validate: true,
+			hostJSON: []byte(`{"version":`),
+			wantErr:  ErrHostConfigMalformed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "host.json"), test.hostJSON, 0644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			functions := map[string]string{
+				"hello-queue": `{"bindings":[{"name":"queue","type":"queueTrigger","direction":"in"}]}`,
+				"user":        `{"bindings":[{"name":"req","type":"httpTrigger","direction":"in","route":"users/{id}"},{"name":"res","type":"http","direction":"out"}]}`,
+			}
+			for name, b := range functions {
+				if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, name, "function.json"), []byte(b), 0644); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			options := []FunctionAppOption{WithDisableLogging(), testConfigDir(dir)}
+			if test.validate {
+				options = append(options, WithValidation(dir))
+			}
+			app := NewFunctionApp(options...)
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				return nil
+			}))
+			app.AddFunction("user", testHTTPHandler("user", "users/{id}"))
+
+			gotErr := app.setup()
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("setup() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if gotErr != nil {
+				return
+			}
+			if diff := cmp.Diff(test.wantTimeout, app.functionTimeout); diff != "" {
+				t.Errorf("setup() = unexpected function timeout (-want +got)\n%s\n", diff)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+			app.router.ServeHTTP(w, r)
+			if diff := cmp.Diff(http.StatusOK, w.Code); diff != "" {
+				t.Errorf("setup() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNewDurableClient(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			body []byte
+			name string
+		}
+		want    string
+		wantErr error
+	}{
+		{
+			name: "binding as string",
+			input: struct {
+				body []byte
+				name string
+			}{
+				body: []byte(`{"Data":{"starter":"{\"taskHubName\":\"hub\",\"baseUrl\":\"http://localhost:7071/runtime/webhooks/durabletask\"}"}}`),
+				name: "starter",
+			},
+			want: "hub",
+		},
+		{
+			name: "binding as object",
+			input: struct {
+				body []byte
+				name string
+			}{
+				body: []byte(`{"Data":{"starter":{"taskHubName":"hub","baseUrl":"http://localhost:7071/runtime/webhooks/durabletask"}}}`),
+				name: "starter",
+			},
+			want: "hub",
+		},
+		{
+			name: "binding not found",
+			input: struct {
+				body []byte
+				name string
+			}{
+				body: []byte(`{"Data":{}}`),
+				name: "starter",
+			},
+			wantErr: ErrDurableClientNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &http.Request{Body: io.NopCloser(bytes.NewReader(test.input.body))}
+			got, gotErr := newDurableClient(r, test.input.name)
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("newDurableClient() = unexpected error (-want +got)\n%s\n", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+
+			if got.TaskHubName() != test.want {
+				t.Errorf("newDurableClient() = unexpected result, want: %s, got: %s\n", test.want, got.TaskHubName())
+			}
+
+			b, _ := io.ReadAll(r.Body)
+			if diff := cmp.Diff(test.input.body, b); diff != "" {
+				t.Errorf("newDurableClient() = unexpected body (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNewDurableClient_HTTPClient(t *testing.T) {
+	body := []byte(`{"Data":{"starter":{"taskHubName":"hub","baseUrl":"http://localhost:7071/runtime/webhooks/durabletask"}}}`)
+	r := &http.Request{Body: io.NopCloser(bytes.NewReader(body))}
+	httpClient := &testDurableHTTPClient{}
+
+	client, err := newDurableClient(r, "starter", func(o *durable.ClientOptions) {
+		o.HTTPClient = httpClient
+	})
+	if err != nil {
+		t.Fatalf("newDurableClient() = unexpected error: %v", err)
+	}
+	client.Status(context.Background(), "abc")
+
+	want := []string{"http://localhost:7071/runtime/webhooks/durabletask/instances/abc"}
+	if diff := cmp.Diff(want, httpClient.urls); diff != "" {
+		t.Errorf("newDurableClient() = unexpected requests (-want +got)\n%s\n", diff)
+	}
+}
+
+type testDurableHTTPClient struct {
+	urls []string
+}
+
+func (c *testDurableHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.urls = append(c.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"instanceId":"abc","runtimeStatus":"Completed"}`))),
+	}, nil
+}
+
+func TestFunctionApp_InvocationContext(t *testing.T) {
+	t.Run("function timeout", func(t *testing.T) {
+		app := NewFunctionApp(func(a *functionApp) {
+			a.functionTimeout = time.Hour
+		})
+
+		ctx, cancel := app.invocationContext(context.Background(), function{timeout: time.Minute})
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("invocationContext() = unexpected deadline: %v\n", deadline)
+		}
+	})
+
+	t.Run("cancelled when stopped", func(t *testing.T) {
+		app := NewFunctionApp()
+
+		ctx, cancel := app.invocationContext(context.Background(), function{})
+		defer cancel()
+
+		app.cancel()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Errorf("invocationContext() = context not cancelled\n")
+		}
+	})
+}
+
+func TestFunctionApp_Handler_Context(t *testing.T) {
+	app := NewFunctionApp(WithDisableLogging(), func(a *functionApp) {
+		a.functionTimeout = time.Minute
+	})
+
+	var hasDeadline, done bool
+	app.AddFunction("hello-http", HTTPTrigger(func(ctx *Context, trigger *trigger.HTTP) error {
+		_, hasDeadline = ctx.Deadline()
+
+		app.cancel()
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-time.After(time.Second):
+		}
+		return ctx.Err()
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hello-http", bytes.NewReader([]byte(`{"Data":{"req":{}},"Metadata":{}}`)))
+	app.handler(app.functions["hello-http"]).ServeHTTP(w, r)
+
+	if !hasDeadline {
+		t.Errorf("handler() = expected Context to have a deadline\n")
+	}
+	if !done {
+		t.Errorf("handler() = expected Context to be cancelled when the FunctionApp is stopped\n")
+	}
+}
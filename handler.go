@@ -0,0 +1,410 @@
+package azfunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KarlGW/azfunc/trigger"
+)
+
+const (
+	// defaultRoutePrefix is the default route prefix of HTTP triggered
+	// functions.
+	defaultRoutePrefix = "api"
+)
+
+var (
+	// ErrHTTPHandlerNotForwarded is returned when a function registered with
+	// HTTPHandler is invoked with the invocation payload of the function host
+	// instead of the forwarded HTTP request, and when the FunctionApp is started
+	// with enableForwardingHttpRequest disabled in host.json.
+	ErrHTTPHandlerNotForwarded = errors.New("http handler requires enableForwardingHttpRequest")
+	// ErrHTTPHandlerMixed is returned when functions registered with HTTPHandler
+	// and HTTP triggered functions that receive the invocation payload are
+	// registered to the same FunctionApp.
+	ErrHTTPHandlerMixed = errors.New("http handlers cannot be registered with http triggers")
+	// ErrHTTPRouteConflict is returned when the routes of two functions
+	// registered with HTTPHandler match the same requests.
+	ErrHTTPRouteConflict = errors.New("http handler routes conflict")
+)
+
+// httpHandlerTrigger contains the handler and options of an HTTP
+// triggered function that receives the forwarded HTTP request.
+type httpHandlerTrigger struct {
+	handler http.Handler
+	options []trigger.HTTPOption
+}
+
+// run returns ErrHTTPHandlerNotForwarded. It is run when the function
+// host invokes the function with the invocation payload, which it does
+// when enableForwardingHttpRequest is not set.
+func (t httpHandlerTrigger) run(ctx *Context, r *http.Request) error {
+	return ErrHTTPHandlerNotForwarded
+}
+
+// binding returns the binding of the trigger.
+func (t httpHandlerTrigger) binding() binding {
+	return httpBinding(t.options)
+}
+
+// route returns the route template of the handler for the function with
+// the provided name. The route defaults to the name of the function.
+func (t httpHandlerTrigger) route(name string) string {
+	opts := applyOptions(trigger.HTTPOptions{}, t.options)
+	route := strings.Trim(opts.Route, "/")
+	if len(route) == 0 {
+		route = name
+	}
+	return route
+}
+
+// HTTPHandler sets the provided http.Handler as the function to be run
+// by an HTTP trigger. The handler receives the HTTP request as forwarded
+// by the function host, and requires enableForwardingHttpRequest to be set
+// in host.json. It is served on /<route prefix>/<route>, where the route
+// prefix is read from extensions.http.routePrefix in host.json (defaults
+// to api) and the route defaults to the name of the function. The route
+// parameters are available with RouteParams.
+func HTTPHandler(handler http.Handler, options ...trigger.HTTPOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = httpHandlerTrigger{
+			handler: handler,
+			options: options,
+		}
+	}
+}
+
+// HTTPHandlerFunc sets the provided function as the function to be run
+// by an HTTP trigger. See HTTPHandler.
+func HTTPHandlerFunc(fn func(w http.ResponseWriter, r *http.Request), options ...trigger.HTTPOption) FunctionOption {
+	return HTTPHandler(http.HandlerFunc(fn), options...)
+}
+
+// forwardedHandler takes the provided function registered with HTTPHandler
+// and serves the forwarded HTTP request with its handler. The handler is run
+// with the same middleware, panic recovery, timeout, tracing and metrics as
+// other functions, and the request has the *Context of the invocation as its
+// context. If the middleware returns an error, or the handler panics, before
+// the response has been written, the error handler writes the response.
+func (a functionApp) forwardedHandler(fn function, t httpHandlerTrigger) http.Handler {
+	info := fn.info()
+	middleware := append(append([]Middleware{}, a.middleware...), fn.middleware...)
+
+	return a.inFlightHandler(a.concurrencyHandler(fn, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqCtx, cancel := a.invocationContext(r.Context(), fn)
+		defer cancel()
+
+		inv := newInvocation(r, info)
+		inv.forwarded = true
+		var span Span
+		if a.tracer != nil {
+			reqCtx, span = a.tracer.Start(reqCtx, headerCarrier(r.Header), inv.spanInfo(info))
+		}
+
+		ctx := newContext(reqCtx, func(o *contextOptions) {
+			o.outputs = newOutputs()
+			o.log = a.log
+			o.services = a.services
+			o.clients = a.clients
+			o.invocation = inv
+			o.tracer = a.tracer
+			o.registry = a.registry
+			o.request = r
+		})
+		defer func() {
+			if err := ctx.scope.close(); err != nil {
+				a.log.Error(err.Error())
+			}
+		}()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		run := a.recoverer(info, chain(info, func(ctx *Context) error {
+			t.handler.ServeHTTP(rec, r.WithContext(ctx))
+			return nil
+		}, middleware...))
+
+		err := run(ctx)
+		if span != nil {
+			span.End(err)
+		}
+		if a.metrics != nil {
+			a.metrics.record(info, time.Since(inv.startTime), r.ContentLength, err != nil || rec.statusCode >= http.StatusInternalServerError)
+		}
+		if err == nil {
+			return
+		}
+		if rec.statusCode != 0 {
+			// The response has been written by the handler.
+			ctx.Log().Error(err.Error())
+			return
+		}
+		errorHandler := a.errorHandler
+		if errorHandler == nil {
+			errorHandler = DefaultErrorHandler
+		}
+		errorHandler(ctx, info, rec, err)
+	})))
+}
+
+// httpRouter serves the functions registered with HTTPHandler on the
+// route prefix, and dispatches requests by the route templates of the
+// functions.
+type httpRouter struct {
+	prefix string
+	routes []httpRoute
+}
+
+// httpRoute contains the route template and handler of a function.
+type httpRoute struct {
+	name     string
+	segments []routeSegment
+	handler  http.Handler
+}
+
+// routeSegment is a segment of a route template. It is either a literal,
+// a parameter, an optional parameter or a catch-all parameter.
+type routeSegment struct {
+	literal  string
+	param    string
+	optional bool
+	catchAll bool
+}
+
+// newHTTPRouter creates a new httpRouter on the provided route prefix.
+func newHTTPRouter(prefix string) *httpRouter {
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) > 0 {
+		prefix = "/" + prefix
+	}
+	return &httpRouter{prefix: prefix + "/"}
+}
+
+// add the handler of the function with the provided name on the provided
+// route template. Returns ErrHTTPRouteConflict if a handler with the same
+// route has already been added.
+func (rt *httpRouter) add(name, route string, handler http.Handler) error {
+	r := httpRoute{
+		name:     name,
+		segments: parseRoute(route),
+		handler:  handler,
+	}
+	for _, route := range rt.routes {
+		if route.key() == r.key() {
+			return fmt.Errorf("%w: functions %s and %s", ErrHTTPRouteConflict, route.name, r.name)
+		}
+	}
+	rt.routes = append(rt.routes, r)
+	sort.SliceStable(rt.routes, func(i, j int) bool {
+		return rt.routes[i].precedes(rt.routes[j])
+	})
+	return nil
+}
+
+// pattern returns the pattern of the router, the route prefix as
+// a subtree.
+func (rt *httpRouter) pattern() string {
+	return rt.prefix
+}
+
+// ServeHTTP dispatches the request to the handler of the first route that
+// matches the path. The route parameters are set on the context of the
+// request.
+func (rt *httpRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, rt.prefix), "/")
+	for _, route := range rt.routes {
+		if params, ok := route.match(path); ok {
+			route.handler.ServeHTTP(w, withRouteParams(r, params))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// withRouteParams returns a shallow copy of the request with the provided
+// route parameters set on its context.
+func withRouteParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeParamsKey{}, params))
+}
+
+// httpHandlers returns true if any function is registered with HTTPHandler.
+// Returns ErrHTTPHandlerMixed if HTTP triggered functions that receive the
+// invocation payload are registered as well, since enableForwardingHttpRequest
+// applies to all HTTP triggered functions.
+func (a functionApp) httpHandlers() (bool, error) {
+	var handlers, triggers []string
+	for name, function := range a.functions {
+		if _, ok := function.trigger.(httpHandlerTrigger); ok {
+			handlers = append(handlers, name)
+		} else if function.trigger != nil && function.trigger.binding().Type == "httpTrigger" {
+			triggers = append(triggers, name)
+		}
+	}
+	if len(handlers) > 0 && len(triggers) > 0 {
+		sort.Strings(handlers)
+		sort.Strings(triggers)
+		return false, fmt.Errorf("%w: handlers: %s, triggers: %s", ErrHTTPHandlerMixed, strings.Join(handlers, ", "), strings.Join(triggers, ", "))
+	}
+	return len(handlers) > 0, nil
+}
+
+// parseRoute parses the provided route template into segments. Constraints
+// and default values of parameters ({id:int}, {id=1}) are not evaluated.
+func parseRoute(route string) []routeSegment {
+	route = strings.Trim(route, "/")
+	if len(route) == 0 {
+		return nil
+	}
+	parts := strings.Split(route, "/")
+	segments := make([]routeSegment, 0, len(parts))
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			segments = append(segments, routeSegment{literal: part})
+			continue
+		}
+		param := part[1 : len(part)-1]
+		var segment routeSegment
+		if strings.HasPrefix(param, "*") {
+			segment.catchAll = true
+			param = strings.TrimLeft(param, "*")
+		}
+		if strings.HasSuffix(param, "?") {
+			segment.optional = true
+			param = strings.TrimSuffix(param, "?")
+		}
+		if i := strings.IndexAny(param, ":="); i >= 0 {
+			param = param[:i]
+		}
+		segment.param = param
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// match the provided path (without route prefix) against the route. Returns
+// the route parameters and true if it matches.
+func (r httpRoute) match(path string) (map[string]string, bool) {
+	var parts []string
+	if len(path) > 0 {
+		parts = strings.Split(path, "/")
+	}
+
+	params := make(map[string]string)
+	for i, segment := range r.segments {
+		if segment.catchAll {
+			if i < len(parts) {
+				params[segment.param] = strings.Join(parts[i:], "/")
+			}
+			return params, true
+		}
+		if i >= len(parts) {
+			if segment.optional {
+				continue
+			}
+			return nil, false
+		}
+		if len(segment.param) == 0 {
+			if !strings.EqualFold(segment.literal, parts[i]) {
+				return nil, false
+			}
+			continue
+		}
+		if len(parts[i]) == 0 {
+			return nil, false
+		}
+		params[segment.param] = parts[i]
+	}
+	if len(parts) > len(r.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// key returns the key of the route. Routes with the same key match the
+// same requests.
+func (r httpRoute) key() string {
+	keys := make([]string, len(r.segments))
+	for i, segment := range r.segments {
+		switch {
+		case segment.catchAll:
+			keys[i] = "{*}"
+		case segment.optional:
+			keys[i] = "{?}"
+		case len(segment.param) > 0:
+			keys[i] = "{}"
+		default:
+			keys[i] = strings.ToLower(segment.literal)
+		}
+	}
+	return strings.Join(keys, "/")
+}
+
+// precedes returns true if the route should be matched before the provided
+// route. Literals precede parameters, parameters precede optional parameters
+// and optional parameters precede catch-all parameters.
+func (r httpRoute) precedes(other httpRoute) bool {
+	for i := 0; i < len(r.segments) && i < len(other.segments); i++ {
+		if a, b := r.segments[i].rank(), other.segments[i].rank(); a != b {
+			return a < b
+		}
+	}
+	return len(r.segments) > len(other.segments)
+}
+
+// rank returns the rank of the segment used for route precedence.
+func (s routeSegment) rank() int {
+	switch {
+	case s.catchAll:
+		return 3
+	case s.optional:
+		return 2
+	case len(s.param) > 0:
+		return 1
+	}
+	return 0
+}
+
+// statusRecorder wraps around an http.ResponseWriter and records
+// the status code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+// WriteHeader records the status code and writes it to the underlying
+// http.ResponseWriter.
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes the data to the underlying http.ResponseWriter.
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes the buffered data of the underlying http.ResponseWriter,
+// if supported.
+func (w *statusRecorder) Flush() {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for it to be used
+// with http.ResponseController (e.g. to flush a streamed response).
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,505 @@
+package azfunc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFunctionApp_Setup_HTTPHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			host   string
+			method string
+			path   string
+		}
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "route parameter",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodGet,
+				path:   "/api/users/1",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "user: map[id:1]",
+		},
+		{
+			name: "route parameter with literal",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodGet,
+				path:   "/api/users/1/orders",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "orders: map[id:1]",
+		},
+		{
+			name: "literal before parameter",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodGet,
+				path:   "/api/users/me",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "me: map[]",
+		},
+		{
+			name: "optional parameter",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodGet,
+				path:   "/api/hello",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "hello: map[]",
+		},
+		{
+			name: "default route",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodGet,
+				path:   "/api/items",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "items: map[]",
+		},
+		{
+			name: "not found",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodGet,
+				path:   "/api/users/1/orders/2",
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "404 page not found\n",
+		},
+		{
+			name: "route prefix",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				host:   `{"version":"2.0","extensions":{"http":{"routePrefix":"v1"}},"customHandler":{"enableForwardingHttpRequest":true}}`,
+				method: http.MethodGet,
+				path:   "/v1/users/1",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "user: map[id:1]",
+		},
+		{
+			name: "empty route prefix",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				host:   `{"version":"2.0","extensions":{"http":{"routePrefix":""}},"customHandler":{"enableForwardingHttpRequest":true}}`,
+				method: http.MethodGet,
+				path:   "/users/1/orders",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "orders: map[id:1]",
+		},
+		{
+			name: "queue trigger",
+			input: struct {
+				host   string
+				method string
+				path   string
+			}{
+				method: http.MethodPost,
+				path:   "/queue",
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"Outputs":{},"ReturnValue":null,"Logs":null}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if len(test.input.host) > 0 {
+				if err := os.WriteFile(filepath.Join(dir, "host.json"), []byte(test.input.host), 0644); err != nil {
+					t.Fatalf("setup() = unexpected error: %v", err)
+				}
+			}
+
+			app := NewFunctionApp(WithDisableLogging(), testConfigDir(dir))
+			app.AddFunction("user", testHTTPHandler("user", "users/{id}"))
+			app.AddFunction("orders", testHTTPHandler("orders", "users/{id:int}/orders"))
+			app.AddFunction("me", testHTTPHandler("me", "users/me"))
+			app.AddFunction("hello", testHTTPHandler("hello", "hello/{name?}"))
+			app.AddFunction("items", testHTTPHandler("items", ""))
+			app.AddFunction("queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				return nil
+			}))
+
+			if err := app.setup(); err != nil {
+				t.Fatalf("setup() = unexpected error: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(test.input.method, test.input.path, nil)
+			if test.input.method == http.MethodPost {
+				r = httptest.NewRequest(test.input.method, test.input.path, strings.NewReader(`{"Data":{"queue":"hello"},"Metadata":{}}`))
+			}
+			app.router.ServeHTTP(w, r)
+
+			if diff := cmp.Diff(test.wantStatus, w.Code); diff != "" {
+				t.Errorf("setup() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantBody, w.Body.String()); diff != "" {
+				t.Errorf("setup() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_Setup_HTTPHandler_Error(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			host      string
+			functions map[string]FunctionOption
+		}
+		wantErr error
+	}{
+		{
+			name: "route conflict",
+			input: struct {
+				host      string
+				functions map[string]FunctionOption
+			}{
+				functions: map[string]FunctionOption{
+					"user":    testHTTPHandler("user", "users/{id}"),
+					"profile": testHTTPHandler("profile", "Users/{name:alpha}"),
+				},
+			},
+			wantErr: ErrHTTPRouteConflict,
+		},
+		{
+			name: "route conflict with function name",
+			input: struct {
+				host      string
+				functions map[string]FunctionOption
+			}{
+				functions: map[string]FunctionOption{
+					"users": testHTTPHandler("users", ""),
+					"list":  testHTTPHandler("list", "/users"),
+				},
+			},
+			wantErr: ErrHTTPRouteConflict,
+		},
+		{
+			name: "mixed with http trigger",
+			input: struct {
+				host      string
+				functions map[string]FunctionOption
+			}{
+				functions: map[string]FunctionOption{
+					"user":  testHTTPHandler("user", "users/{id}"),
+					"hello": HTTPTrigger(nil),
+				},
+			},
+			wantErr: ErrHTTPHandlerMixed,
+		},
+		{
+			name: "forwarding disabled",
+			input: struct {
+				host      string
+				functions map[string]FunctionOption
+			}{
+				host: `{"version":"2.0","customHandler":{"enableForwardingHttpRequest":false}}`,
+				functions: map[string]FunctionOption{
+					"user": testHTTPHandler("user", "users/{id}"),
+				},
+			},
+			wantErr: ErrHTTPHandlerNotForwarded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if len(test.input.host) > 0 {
+				if err := os.WriteFile(filepath.Join(dir, "host.json"), []byte(test.input.host), 0644); err != nil {
+					t.Fatalf("setup() = unexpected error: %v", err)
+				}
+			}
+
+			app := NewFunctionApp(WithDisableLogging(), testConfigDir(dir))
+			for name, option := range test.input.functions {
+				app.AddFunction(name, option)
+			}
+
+			gotErr := app.setup()
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("setup() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_ForwardedHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			handler    http.HandlerFunc
+			middleware Middleware
+		}
+		wantStatus    int
+		wantBody      string
+		wantPanicHook bool
+	}{
+		{
+			name: "context",
+			input: struct {
+				handler    http.HandlerFunc
+				middleware Middleware
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					ctx, ok := r.Context().(*Context)
+					if !ok {
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+					_, deadline := ctx.Deadline()
+					fmt.Fprintf(w, "%s %s %t %v", ctx.FunctionName(), ctx.InvocationID(), deadline, RouteParams(r))
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "user abcd true map[id:1]",
+		},
+		{
+			name: "middleware",
+			input: struct {
+				handler    http.HandlerFunc
+				middleware Middleware
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(r.Header.Get("X-Middleware")))
+				},
+				middleware: func(ctx *Context, info FunctionInfo, next NextFunc) error {
+					ctx.request.Header.Set("X-Middleware", info.Name+" "+info.TriggerType)
+					return next(ctx)
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "user httpTrigger",
+		},
+		{
+			name: "middleware error",
+			input: struct {
+				handler    http.HandlerFunc
+				middleware Middleware
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {},
+				middleware: func(ctx *Context, info FunctionInfo, next NextFunc) error {
+					return BadRequest(errTestFunction)
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"` + errTestFunction.Error() + `","instance":"abcd"}`,
+		},
+		{
+			name: "panic",
+			input: struct {
+				handler    http.HandlerFunc
+				middleware Middleware
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					panic("panic")
+				},
+			},
+			wantStatus:    http.StatusInternalServerError,
+			wantBody:      `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"abcd"}`,
+			wantPanicHook: true,
+		},
+		{
+			name: "panic after response is written",
+			input: struct {
+				handler    http.HandlerFunc
+				middleware Middleware
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusAccepted)
+					panic("panic")
+				},
+			},
+			wantStatus:    http.StatusAccepted,
+			wantPanicHook: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var panicHook bool
+			options := []FunctionAppOption{
+				WithDisableLogging(),
+				WithPanicHook(func(ctx *Context, info FunctionInfo, err *PanicError) {
+					panicHook = true
+				}),
+				testConfigDir(t.TempDir()),
+			}
+			if test.input.middleware != nil {
+				options = append(options, WithMiddleware(test.input.middleware))
+			}
+			app := NewFunctionApp(options...)
+			app.AddFunction("user", HTTPHandler(test.input.handler, func(o *trigger.HTTPOptions) {
+				o.Route = "users/{id}"
+			}), WithFunctionTimeout(time.Minute))
+
+			if err := app.setup(); err != nil {
+				t.Fatalf("setup() = unexpected error: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+			r.Header.Set(headerInvocationID, "abcd")
+			app.router.ServeHTTP(w, r)
+
+			if diff := cmp.Diff(test.wantStatus, w.Code); diff != "" {
+				t.Errorf("forwardedHandler() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantBody, w.Body.String()); diff != "" {
+				t.Errorf("forwardedHandler() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantPanicHook, panicHook); diff != "" {
+				t.Errorf("forwardedHandler() = unexpected panic hook call (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_HostConfig_HTTPHandler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			functions map[string]FunctionOption
+			options   []ConfigOption
+		}
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "http handler",
+			input: struct {
+				functions map[string]FunctionOption
+				options   []ConfigOption
+			}{
+				functions: map[string]FunctionOption{
+					"hello": HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+				},
+			},
+			want: true,
+		},
+		{
+			name: "http trigger",
+			input: struct {
+				functions map[string]FunctionOption
+				options   []ConfigOption
+			}{
+				functions: map[string]FunctionOption{
+					"hello": HTTPTrigger(nil),
+				},
+			},
+			want: false,
+		},
+		{
+			name: "mixed",
+			input: struct {
+				functions map[string]FunctionOption
+				options   []ConfigOption
+			}{
+				functions: map[string]FunctionOption{
+					"hello":   HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+					"goodbye": HTTPTrigger(nil),
+				},
+			},
+			wantErr: ErrHTTPHandlerMixed,
+		},
+		{
+			name: "http handler with forwarding disabled",
+			input: struct {
+				functions map[string]FunctionOption
+				options   []ConfigOption
+			}{
+				functions: map[string]FunctionOption{
+					"hello": HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+				},
+				options: []ConfigOption{func(o *ConfigOptions) {
+					o.EnableForwardingHTTPRequest = false
+				}},
+			},
+			wantErr: ErrHTTPHandlerNotForwarded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp()
+			for name, option := range test.input.functions {
+				app.AddFunction(name, option)
+			}
+
+			got, gotErr := app.hostConfig(append([]ConfigOption{func(o *ConfigOptions) {
+				o.ExecutablePath = "handler"
+			}}, test.input.options...)...)
+
+			if diff := cmp.Diff(test.want, got.CustomHandler.EnableForwardingHTTPRequest); diff != "" {
+				t.Errorf("hostConfig() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("hostConfig() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func testHTTPHandler(name, route string) FunctionOption {
+	return HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s: %v", name, RouteParams(r))
+	}, func(o *trigger.HTTPOptions) {
+		o.Route = route
+	})
+}
+
+func testConfigDir(dir string) FunctionAppOption {
+	return func(a *functionApp) {
+		a.configDir = dir
+	}
+}
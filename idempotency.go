@@ -0,0 +1,362 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/KarlGW/azfunc/trigger"
+)
+
+const (
+	// defaultIdempotencyTTL is the default time the key of a completed
+	// invocation is kept.
+	defaultIdempotencyTTL = 24 * time.Hour
+	// defaultIdempotencyLockTTL is the default time the key of an
+	// invocation in progress is kept.
+	defaultIdempotencyLockTTL = 5 * time.Minute
+	// memoryIdempotencyStoreSweepInterval is the interval at which expired
+	// records are removed from a MemoryIdempotencyStore.
+	memoryIdempotencyStoreSweepInterval = time.Minute
+)
+
+var (
+	// ErrInvocationInProgress is returned (as a transient error) when an
+	// invocation with the same idempotency key is in progress.
+	ErrInvocationInProgress = errors.New("invocation with the same idempotency key in progress")
+)
+
+// IdempotencyRecord contains the state of an invocation with an
+// idempotency key.
+type IdempotencyRecord struct {
+	// Completed is true when the invocation has completed successfully.
+	// A record that is not completed is held by an invocation in progress.
+	Completed bool
+	// Outputs contains the data of the output bindings of the completed
+	// invocation, if cached.
+	Outputs map[string]data.Raw
+	// ReturnValue contains the JSON encoded return value of the completed
+	// invocation, if cached.
+	ReturnValue json.RawMessage
+}
+
+// IdempotencyStore is the interface that wraps around the methods Add, Get,
+// Set and Delete. It stores the records of idempotency keys.
+type IdempotencyStore interface {
+	// Add the record with the key if it does not exist (or has expired).
+	// Returns false if it exists.
+	Add(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (bool, error)
+	// Get returns the record of the key. Returns false if it does not exist
+	// (or has expired).
+	Get(ctx context.Context, key string) (IdempotencyRecord, bool, error)
+	// Set the record of the key.
+	Set(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+	// Delete the record of the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// IdempotencyKeyFunc is a function that returns the idempotency key of an
+// invocation from its trigger. An empty key runs the invocation without
+// de-duplication.
+type IdempotencyKeyFunc func(ctx *Context, trigger *trigger.Generic) (string, error)
+
+// IdempotencyOptions contains options for the idempotency middleware.
+type IdempotencyOptions struct {
+	// Key sets the function that returns the idempotency key of an
+	// invocation. Defaults to the ID of the message of Queue Storage (ID),
+	// Service Bus (MessageID) and Event Grid (ID) triggers.
+	Key IdempotencyKeyFunc
+	// TTL sets how long the key of a completed invocation is kept, during
+	// which duplicates are skipped. Defaults to 24 hours.
+	TTL time.Duration
+	// LockTTL sets how long the key of an invocation in progress is kept,
+	// should it not complete (e.g. if the process is stopped). Defaults to
+	// 5 minutes.
+	LockTTL time.Duration
+	// CacheOutputs sets the output bindings and return value of completed
+	// invocations to be cached, and replayed for duplicates. HTTP output
+	// bindings are not cached.
+	CacheOutputs bool
+}
+
+// IdempotencyOption is a function that sets options for the idempotency
+// middleware.
+type IdempotencyOption func(o *IdempotencyOptions)
+
+// Idempotency returns a middleware that de-duplicates invocations by their
+// idempotency key, with the records in the provided store. A duplicate of a
+// completed invocation is skipped (completed without running the function),
+// with the cached outputs if CacheOutputs is set. A duplicate of an
+// invocation in progress fails with ErrInvocationInProgress, for the function
+// host to retry it. The key of a failed invocation is removed, so that it
+// can be retried. Keys are prefixed with the name of the function.
+// Invocations without a key, and functions registered with HTTPHandler,
+// are run without de-duplication.
+func Idempotency(store IdempotencyStore, options ...IdempotencyOption) Middleware {
+	opts := IdempotencyOptions{
+		TTL:     defaultIdempotencyTTL,
+		LockTTL: defaultIdempotencyLockTTL,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	defaultKey := opts.Key == nil
+	if defaultKey {
+		opts.Key = defaultIdempotencyKey
+	}
+
+	return func(ctx *Context, info FunctionInfo, next NextFunc) error {
+		// Functions registered with HTTPHandler are forwarded HTTP requests
+		// without a trigger payload, and triggers without a message ID have
+		// no default key.
+		if ctx.invocation.forwarded || (defaultKey && !hasDefaultIdempotencyKey(info.TriggerType)) {
+			return next(ctx)
+		}
+		t, err := newGenericTrigger(ctx.request, info.TriggerName)
+		if err != nil {
+			ctx.Log().Debug("Could not parse trigger, running without de-duplication.", "error", err.Error())
+			return next(ctx)
+		}
+		key, err := opts.Key(ctx, t)
+		if err != nil {
+			return err
+		}
+		if len(key) == 0 {
+			ctx.Log().Debug("No idempotency key, running without de-duplication.")
+			return next(ctx)
+		}
+		key = info.Name + ":" + key
+
+		added, err := store.Add(ctx, key, IdempotencyRecord{}, opts.LockTTL)
+		if err != nil {
+			return err
+		}
+		if !added {
+			record, ok, err := store.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+			if !ok || !record.Completed {
+				return Transient(fmt.Errorf("%w: %s", ErrInvocationInProgress, key))
+			}
+			record.replay(ctx.Outputs)
+			ctx.Log().Info("Duplicate invocation skipped.", "idempotencyKey", key)
+			ctx.Outputs.Log().Info("Duplicate invocation skipped.", "idempotencyKey", key)
+			return nil
+		}
+
+		var completed bool
+		defer func() {
+			// Remove the key if the invocation failed or panicked, so that
+			// it can be retried.
+			if completed {
+				return
+			}
+			if err := store.Delete(context.WithoutCancel(ctx), key); err != nil {
+				ctx.Log().Error(err.Error(), "idempotencyKey", key)
+			}
+		}()
+
+		if err := next(ctx); err != nil {
+			return err
+		}
+		completed = true
+
+		record := IdempotencyRecord{Completed: true}
+		if opts.CacheOutputs {
+			record.Outputs, record.ReturnValue = cachedOutputs(ctx.Outputs)
+		}
+		if err := store.Set(context.WithoutCancel(ctx), key, record, opts.TTL); err != nil {
+			ctx.Log().Error(err.Error(), "idempotencyKey", key)
+		}
+		return nil
+	}
+}
+
+// defaultIdempotencyKey returns the ID of the message of Queue Storage,
+// Service Bus and Event Grid triggers. Returns an empty key for other
+// triggers.
+func defaultIdempotencyKey(ctx *Context, t *trigger.Generic) (string, error) {
+	switch ctx.TriggerType() {
+	case "queueTrigger":
+		return metadataString(t.Metadata, "Id"), nil
+	case "serviceBusTrigger":
+		return metadataString(t.Metadata, "MessageId"), nil
+	case "eventGridTrigger":
+		var event struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(t.Data, &event); err != nil {
+			// Not an event, run without a key.
+			return "", nil
+		}
+		return event.ID, nil
+	}
+	return "", nil
+}
+
+// hasDefaultIdempotencyKey returns true if defaultIdempotencyKey returns
+// a key for the provided trigger type.
+func hasDefaultIdempotencyKey(triggerType string) bool {
+	switch triggerType {
+	case "queueTrigger", "serviceBusTrigger", "eventGridTrigger":
+		return true
+	}
+	return false
+}
+
+// newGenericTrigger creates a generic trigger from the provided request.
+// The body of the request is restored to be read by the trigger of the
+// function.
+func newGenericTrigger(r *http.Request, name string) (*trigger.Generic, error) {
+	if r == nil {
+		return &trigger.Generic{}, nil
+	}
+	b, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		r.Body = io.NopCloser(bytes.NewReader(b))
+	}()
+	return trigger.NewGeneric(r, name)
+}
+
+// cachedOutputs returns the data of the output bindings and the JSON
+// encoded return value of the provided outputs.
+func cachedOutputs(o *outputs) (map[string]data.Raw, json.RawMessage) {
+	bindings := make(map[string]data.Raw, len(o.outputs))
+	for name, binding := range o.outputs {
+		if d := binding.Data(); len(d) > 0 {
+			bindings[name] = append(data.Raw{}, d...)
+		}
+	}
+	var returnValue json.RawMessage
+	if o.returnValue != nil {
+		returnValue, _ = json.Marshal(o.returnValue)
+	}
+	return bindings, returnValue
+}
+
+// replay writes the cached outputs of the record to the provided outputs.
+func (r IdempotencyRecord) replay(o *outputs) {
+	for name, d := range r.Outputs {
+		o.Binding(name).Write(d)
+	}
+	if len(r.ReturnValue) > 0 {
+		o.SetReturnValue(r.ReturnValue)
+	}
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore with expiring
+// records. It is local to the process, and should only be used when the
+// FunctionApp runs on a single instance.
+type MemoryIdempotencyStore struct {
+	records   map[string]memoryIdempotencyRecord
+	lastSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// memoryIdempotencyRecord contains a record and its expiration time.
+type memoryIdempotencyRecord struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates and returns a new MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]memoryIdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+// Add the record with the key if it does not exist (or has expired).
+// Returns false if it exists.
+func (s *MemoryIdempotencyStore) Add(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.get(key); ok {
+		return false, nil
+	}
+	s.set(key, record, ttl)
+	return true, nil
+}
+
+// Get returns the record of the key. Returns false if it does not exist
+// (or has expired).
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.get(key)
+	return record, ok, nil
+}
+
+// Set the record of the key.
+func (s *MemoryIdempotencyStore) Set(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(key, record, ttl)
+	return nil
+}
+
+// Delete the record of the key.
+func (s *MemoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// get returns the record of the key if it has not expired. The lock must
+// be held by the caller.
+func (s *MemoryIdempotencyStore) get(key string) (IdempotencyRecord, bool) {
+	r, ok := s.records[key]
+	if !ok {
+		return IdempotencyRecord{}, false
+	}
+	if !s.clock().Before(r.expires) {
+		delete(s.records, key)
+		return IdempotencyRecord{}, false
+	}
+	return r.record, true
+}
+
+// clock returns the current time.
+func (s *MemoryIdempotencyStore) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// set the record of the key, and removes expired records at most once
+// every sweep interval. The lock must be held by the caller.
+func (s *MemoryIdempotencyStore) set(key string, record IdempotencyRecord, ttl time.Duration) {
+	now := s.clock()
+	if s.records == nil {
+		s.records = make(map[string]memoryIdempotencyRecord)
+	}
+	if now.Sub(s.lastSweep) >= memoryIdempotencyStoreSweepInterval {
+		for k, r := range s.records {
+			if !now.Before(r.expires) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.records[key] = memoryIdempotencyRecord{record: record, expires: now.Add(ttl)}
+}
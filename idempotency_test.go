@@ -0,0 +1,333 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdempotency(t *testing.T) {
+	var tests = []struct {
+		name        string
+		options     []IdempotencyOption
+		record      *IdempotencyRecord
+		inputs      []string
+		err         error
+		wantCalls   int
+		wantStatus  []int
+		wantOutputs []map[string]string
+	}{
+		{
+			name:       "duplicate is skipped",
+			inputs:     []string{testIdempotencyQueueRequest("1"), testIdempotencyQueueRequest("1")},
+			wantCalls:  1,
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+			wantOutputs: []map[string]string{
+				{"out": "1"},
+				{},
+			},
+		},
+		{
+			name: "duplicate is replayed",
+			options: []IdempotencyOption{func(o *IdempotencyOptions) {
+				o.CacheOutputs = true
+			}},
+			inputs:     []string{testIdempotencyQueueRequest("1"), testIdempotencyQueueRequest("1")},
+			wantCalls:  1,
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+			wantOutputs: []map[string]string{
+				{"out": "1"},
+				{"out": "1"},
+			},
+		},
+		{
+			name:       "different keys",
+			inputs:     []string{testIdempotencyQueueRequest("1"), testIdempotencyQueueRequest("2")},
+			wantCalls:  2,
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+			wantOutputs: []map[string]string{
+				{"out": "1"},
+				{"out": "2"},
+			},
+		},
+		{
+			name:       "failed invocation is run again",
+			inputs:     []string{testIdempotencyQueueRequest("1"), testIdempotencyQueueRequest("1")},
+			err:        errTestFunction,
+			wantCalls:  2,
+			wantStatus: []int{http.StatusInternalServerError, http.StatusInternalServerError},
+		},
+		{
+			name:       "invocation in progress",
+			record:     &IdempotencyRecord{},
+			inputs:     []string{testIdempotencyQueueRequest("1")},
+			wantCalls:  0,
+			wantStatus: []int{http.StatusInternalServerError},
+		},
+		{
+			name: "key function",
+			options: []IdempotencyOption{func(o *IdempotencyOptions) {
+				o.Key = func(ctx *Context, trigger *trigger.Generic) (string, error) {
+					return "key", nil
+				}
+			}},
+			inputs:     []string{testIdempotencyQueueRequest("1"), testIdempotencyQueueRequest("2")},
+			wantCalls:  1,
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+			wantOutputs: []map[string]string{
+				{"out": "1"},
+				{},
+			},
+		},
+		{
+			name: "no key",
+			options: []IdempotencyOption{func(o *IdempotencyOptions) {
+				o.Key = func(ctx *Context, trigger *trigger.Generic) (string, error) {
+					return "", nil
+				}
+			}},
+			inputs:     []string{testIdempotencyQueueRequest("1"), testIdempotencyQueueRequest("1")},
+			wantCalls:  2,
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+			wantOutputs: []map[string]string{
+				{"out": "1"},
+				{"out": "1"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewMemoryIdempotencyStore()
+			if test.record != nil {
+				store.Set(context.Background(), "hello-queue:1", *test.record, time.Minute)
+			}
+
+			var calls int
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				calls++
+				if test.err != nil {
+					return test.err
+				}
+				ctx.Outputs.Binding("out").Write(trigger.Data)
+				return nil
+			}), WithFunctionMiddleware(Idempotency(store, test.options...)))
+			h := app.handler(app.functions["hello-queue"])
+
+			for i, input := range test.inputs {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(input)))
+				h.ServeHTTP(w, r)
+
+				if diff := cmp.Diff(test.wantStatus[i], w.Code); diff != "" {
+					t.Errorf("Idempotency() = unexpected status code (-want +got)\n%s\n", diff)
+				}
+				if w.Code != http.StatusOK {
+					continue
+				}
+
+				var res struct {
+					Outputs map[string]string
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+					t.Fatalf("Idempotency() = unexpected error: %v", err)
+				}
+				if diff := cmp.Diff(test.wantOutputs[i], res.Outputs); diff != "" {
+					t.Errorf("Idempotency() = unexpected outputs (-want +got)\n%s\n", diff)
+				}
+			}
+
+			if diff := cmp.Diff(test.wantCalls, calls); diff != "" {
+				t.Errorf("Idempotency() = unexpected calls (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestIdempotency_NoKey(t *testing.T) {
+	var tests = []struct {
+		name    string
+		options []IdempotencyOption
+	}{
+		{
+			name: "default key",
+		},
+		{
+			name: "key function",
+			options: []IdempotencyOption{func(o *IdempotencyOptions) {
+				o.Key = func(ctx *Context, trigger *trigger.Generic) (string, error) {
+					return "key", nil
+				}
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			app := NewFunctionApp(
+				WithDisableLogging(),
+				WithMiddleware(Idempotency(NewMemoryIdempotencyStore(), test.options...)),
+				testConfigDir(t.TempDir()),
+			)
+			app.AddFunction("user", HTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Write([]byte("user"))
+			}, func(o *trigger.HTTPOptions) {
+				o.Route = "users/{id}"
+			}))
+			if err := app.setup(); err != nil {
+				t.Fatalf("setup() = unexpected error: %v", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+				app.router.ServeHTTP(w, r)
+
+				if diff := cmp.Diff(http.StatusOK, w.Code); diff != "" {
+					t.Errorf("Idempotency() = unexpected status code (-want +got)\n%s\n", diff)
+				}
+				if diff := cmp.Diff("user", w.Body.String()); diff != "" {
+					t.Errorf("Idempotency() = unexpected result (-want +got)\n%s\n", diff)
+				}
+			}
+
+			if diff := cmp.Diff(2, calls); diff != "" {
+				t.Errorf("Idempotency() = unexpected calls (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestIdempotency_MalformedTrigger(t *testing.T) {
+	middleware := Idempotency(NewMemoryIdempotencyStore(), func(o *IdempotencyOptions) {
+		o.Key = func(ctx *Context, trigger *trigger.Generic) (string, error) {
+			return "key", nil
+		}
+	})
+	ctx := newContext(context.Background(), func(o *contextOptions) {
+		o.log = noOpLogger{}
+		o.request = httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`not json`)))
+	})
+
+	var called bool
+	gotErr := middleware(ctx, FunctionInfo{Name: "hello-queue", TriggerType: "queueTrigger", TriggerName: "queue"}, func(ctx *Context) error {
+		called = true
+		return nil
+	})
+
+	if gotErr != nil {
+		t.Errorf("Idempotency() = unexpected error, want: nil, got: %v\n", gotErr)
+	}
+	if !called {
+		t.Errorf("Idempotency() = next not called\n")
+	}
+}
+
+func TestDefaultIdempotencyKey(t *testing.T) {
+	var tests = []struct {
+		name        string
+		triggerType string
+		input       *trigger.Generic
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "queue",
+			triggerType: "queueTrigger",
+			input:       &trigger.Generic{Metadata: map[string]any{"Id": "1234"}},
+			want:        "1234",
+		},
+		{
+			name:        "service bus",
+			triggerType: "serviceBusTrigger",
+			input:       &trigger.Generic{Metadata: map[string]any{"MessageId": `"1234"`}},
+			want:        "1234",
+		},
+		{
+			name:        "event grid",
+			triggerType: "eventGridTrigger",
+			input:       &trigger.Generic{Data: []byte(`{"id":"1234","subject":"subject"}`)},
+			want:        "1234",
+		},
+		{
+			name:        "other trigger",
+			triggerType: "timerTrigger",
+			input:       &trigger.Generic{Metadata: map[string]any{"Id": "1234"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := newContext(context.Background(), func(o *contextOptions) {
+				o.invocation = invocation{triggerType: test.triggerType}
+				o.log = noOpLogger{}
+			})
+
+			got, gotErr := defaultIdempotencyKey(ctx, test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("defaultIdempotencyKey() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("defaultIdempotencyKey() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore()
+	store.now = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	added, _ := store.Add(ctx, "key", IdempotencyRecord{}, time.Minute)
+	if !added {
+		t.Errorf("Add() = expected record to be added\n")
+	}
+	added, _ = store.Add(ctx, "key", IdempotencyRecord{}, time.Minute)
+	if added {
+		t.Errorf("Add() = expected record to exist\n")
+	}
+
+	store.Set(ctx, "key", IdempotencyRecord{Completed: true}, time.Hour)
+	got, ok, _ := store.Get(ctx, "key")
+	if diff := cmp.Diff(IdempotencyRecord{Completed: true}, got); diff != "" || !ok {
+		t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok, _ := store.Get(ctx, "key"); ok {
+		t.Errorf("Get() = expected record to have expired\n")
+	}
+
+	store.Set(ctx, "expired", IdempotencyRecord{}, time.Minute)
+	now = now.Add(2 * time.Minute)
+	store.Set(ctx, "key", IdempotencyRecord{}, time.Minute)
+	if _, ok := store.records["expired"]; ok {
+		t.Errorf("Set() = expected expired records to be removed\n")
+	}
+
+	store.Delete(ctx, "key")
+	if _, ok, _ := store.Get(ctx, "key"); ok {
+		t.Errorf("Delete() = expected record to be deleted\n")
+	}
+}
+
+func testIdempotencyQueueRequest(id string) string {
+	return `{"Data":{"queue":"` + id + `"},"Metadata":{"Id":"` + id + `"}}`
+}
@@ -0,0 +1,169 @@
+package azfunc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultShutdownTimeout is the default time the FunctionApp waits for
+	// in-flight invocations to finish when stopped.
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+// ErrInvocationsInFlight is returned when invocations have not returned
+// within the shutdown timeout after their Context was cancelled.
+var ErrInvocationsInFlight = errors.New("invocations in flight after shutdown timeout")
+
+// Hook is a function that is run when the FunctionApp is started
+// or stopped.
+type Hook func(ctx context.Context) error
+
+// OnStart adds a hook that is run when the FunctionApp is started, before
+// it serves invocations. Hooks are run in the order they were added, and
+// the FunctionApp fails to start if a hook returns an error. The context
+// is cancelled when the FunctionApp is stopped.
+func OnStart(hook Hook) FunctionAppOption {
+	return func(a *functionApp) {
+		if hook != nil {
+			a.startHooks = append(a.startHooks, hook)
+		}
+	}
+}
+
+// OnStop adds a hook that is run when the FunctionApp is stopped, after
+// in-flight invocations have returned, or the shutdown timeout has elapsed
+// again after their Context was cancelled.
+// Hooks are run in the reverse order they were added, with a context that
+// has the shutdown timeout. All hooks are run, and their errors are joined.
+// Functions set with WithShutdownFunc are run after the hooks, in the order
+// they were added.
+func OnStop(hook Hook) FunctionAppOption {
+	return func(a *functionApp) {
+		if hook != nil {
+			a.stopHooks = append(a.stopHooks, hook)
+		}
+	}
+}
+
+// WithShutdownTimeout sets the time the FunctionApp waits for in-flight
+// invocations to finish when stopped, after which their Context is
+// cancelled. It is also the timeout of the stop hooks. Defaults to 10
+// seconds.
+func WithShutdownTimeout(d time.Duration) FunctionAppOption {
+	return func(a *functionApp) {
+		if d > 0 {
+			a.shutdownTimeout = d
+		}
+	}
+}
+
+// start runs the start hooks of the FunctionApp in order. It stops at, and
+// returns, the first error.
+func (a functionApp) start() error {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, hook := range a.startHooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shutdown the FunctionApp. It stops accepting new invocations and waits
+// for in-flight invocations to finish until the shutdown timeout has elapsed,
+// after which their Context is cancelled, and waits for them to return
+// until the shutdown timeout has elapsed again. Then the stop hooks are run in
+// reverse order, followed by the shutdown functions in order, and lastly
+// the admin server is shut down. The errors are joined.
+func (a functionApp) shutdown() error {
+	timeout := a.shutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	if a.draining != nil {
+		a.draining.Store(true)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if a.cancel != nil {
+		stop := context.AfterFunc(ctx, a.cancel)
+		defer stop()
+	}
+
+	var errs []error
+	a.httpServer.SetKeepAlivesEnabled(false)
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if a.cancel != nil {
+		a.cancel()
+	}
+	// Wait for cancelled invocations to return before running the stop
+	// hooks, which can close dependencies they use.
+	if !a.waitInFlight(timeout) {
+		errs = append(errs, ErrInvocationsInFlight)
+	}
+
+	hookCtx, hookCancel := context.WithTimeout(context.Background(), timeout)
+	defer hookCancel()
+	for i := len(a.stopHooks) - 1; i >= 0; i-- {
+		if err := a.stopHooks[i](hookCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, fn := range a.shutdownFuncs {
+		if err := fn(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// The admin server is shut down last, to report that the FunctionApp
+	// is unavailable while it is draining.
+	if a.adminServer != nil {
+		if err := a.adminServer.Shutdown(hookCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// inFlightHandler tracks the invocations of the provided handler as in
+// flight while they are served.
+func (a functionApp) inFlightHandler(h http.Handler) http.Handler {
+	if a.inFlight == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.inFlight.Add(1)
+		defer a.inFlight.Done()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// waitInFlight waits for the invocations in flight to return, until the
+// provided timeout has elapsed. Returns false if they have not returned.
+func (a functionApp) waitInFlight(timeout time.Duration) bool {
+	if a.inFlight == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		a.inFlight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
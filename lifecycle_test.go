@@ -0,0 +1,216 @@
+package azfunc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFunctionApp_Start_Hooks(t *testing.T) {
+	var tests = []struct {
+		name    string
+		hooks   []string
+		fail    string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "run in order",
+			hooks: []string{"first", "second", "third"},
+			want:  []string{"first", "second", "third"},
+		},
+		{
+			name:    "stop at error",
+			hooks:   []string{"first", "second", "third"},
+			fail:    "second",
+			want:    []string{"first", "second"},
+			wantErr: errTestHook,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			var options []FunctionAppOption
+			for _, name := range test.hooks {
+				name := name
+				options = append(options, OnStart(func(ctx context.Context) error {
+					got = append(got, name)
+					if name == test.fail {
+						return errTestHook
+					}
+					return nil
+				}))
+			}
+			app := NewFunctionApp(options...)
+
+			gotErr := app.start()
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("start() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("start() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_Shutdown_Hooks(t *testing.T) {
+	errFirst, errThird := errors.New("first"), errors.New("third")
+	var got []string
+	var hasDeadline bool
+	app := NewFunctionApp(
+		WithDisableLogging(),
+		OnStop(func(ctx context.Context) error {
+			got = append(got, "first")
+			return errFirst
+		}),
+		WithShutdownFunc(func() error {
+			got = append(got, "second")
+			return nil
+		}),
+		OnStop(func(ctx context.Context) error {
+			_, hasDeadline = ctx.Deadline()
+			got = append(got, "third")
+			return errThird
+		}),
+		WithShutdownFunc(func() error {
+			got = append(got, "fourth")
+			return nil
+		}),
+	)
+
+	gotErr := app.shutdown()
+
+	want := []string{"third", "first", "second", "fourth"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("shutdown() = unexpected result (-want +got)\n%s\n", diff)
+	}
+	if !errors.Is(gotErr, errFirst) || !errors.Is(gotErr, errThird) {
+		t.Errorf("shutdown() = unexpected error, want: %v and %v, got: %v\n", errFirst, errThird, gotErr)
+	}
+	if !hasDeadline {
+		t.Errorf("shutdown() = expected stop hook context with deadline\n")
+	}
+	if !app.stopping() {
+		t.Errorf("shutdown() = expected FunctionApp to be stopping\n")
+	}
+}
+
+func TestFunctionApp_Shutdown_Drain(t *testing.T) {
+	var tests = []struct {
+		name         string
+		timeout      time.Duration
+		ignoreCancel bool
+		wantCancel   bool
+		wantEvents   []string
+		wantErr      error
+	}{
+		{
+			name:       "in-flight invocation finishes",
+			timeout:    5 * time.Second,
+			wantEvents: []string{"invocation", "stop hook"},
+		},
+		{
+			name:       "in-flight invocation is cancelled after timeout",
+			timeout:    50 * time.Millisecond,
+			wantCancel: true,
+			wantEvents: []string{"invocation", "stop hook"},
+			wantErr:    context.DeadlineExceeded,
+		},
+		{
+			name:         "in-flight invocation does not return after timeout",
+			timeout:      50 * time.Millisecond,
+			ignoreCancel: true,
+			wantCancel:   true,
+			wantEvents:   []string{"stop hook", "invocation"},
+			wantErr:      ErrInvocationsInFlight,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			started, release := make(chan struct{}), make(chan struct{})
+			var events []string
+			var cancelled bool
+			var mu sync.Mutex
+
+			app := NewFunctionApp(
+				WithDisableLogging(),
+				WithShutdownTimeout(test.timeout),
+				OnStop(func(ctx context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, "stop hook")
+					return nil
+				}),
+			)
+			app.httpServer.Handler = app.inFlightHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx, cancel := app.invocationContext(r.Context(), function{})
+				defer cancel()
+				close(started)
+				if test.ignoreCancel {
+					<-release
+				} else {
+					select {
+					case <-release:
+					case <-ctx.Done():
+					}
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				cancelled = ctx.Err() != nil
+				events = append(events, "invocation")
+			}))
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			go app.httpServer.Serve(l)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				res, err := http.Get("http://" + l.Addr().String())
+				if err == nil {
+					res.Body.Close()
+				}
+			}()
+			<-started
+
+			if !test.wantCancel {
+				time.AfterFunc(20*time.Millisecond, func() {
+					close(release)
+				})
+			}
+			gotErr := app.shutdown()
+			if test.ignoreCancel {
+				close(release)
+			}
+			<-done
+
+			mu.Lock()
+			defer mu.Unlock()
+			if diff := cmp.Diff(test.wantEvents, events); diff != "" {
+				t.Errorf("shutdown() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("shutdown() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if test.wantCancel != cancelled {
+				t.Errorf("shutdown() = unexpected cancellation, want: %v, got: %v\n", test.wantCancel, cancelled)
+			}
+		})
+	}
+}
+
+var errTestHook = errors.New("hook error")
@@ -51,6 +51,49 @@ func (l logger) Warn(msg string, args ...any) {
 	l.stderr.Warn(msg, args...)
 }
 
+// withAttrs returns a Logger that adds the provided attributes (key-value
+// pairs) to every entry.
+func withAttrs(l Logger, args ...any) Logger {
+	switch l := l.(type) {
+	case nil:
+		return nil
+	case logger:
+		return logger{
+			stdout: l.stdout.With(args...),
+			stderr: l.stderr.With(args...),
+		}
+	case noOpLogger:
+		return l
+	}
+	return attrLogger{Logger: l, args: args}
+}
+
+// attrLogger wraps around a Logger and adds attributes to every entry.
+type attrLogger struct {
+	Logger
+	args []any
+}
+
+// Debug logs at [LevelDebug].
+func (l attrLogger) Debug(msg string, args ...any) {
+	l.Logger.Debug(msg, append(l.args[:len(l.args):len(l.args)], args...)...)
+}
+
+// Error logs at [LevelError].
+func (l attrLogger) Error(msg string, args ...any) {
+	l.Logger.Error(msg, append(l.args[:len(l.args):len(l.args)], args...)...)
+}
+
+// Info logs at [LevelInfo].
+func (l attrLogger) Info(msg string, args ...any) {
+	l.Logger.Info(msg, append(l.args[:len(l.args):len(l.args)], args...)...)
+}
+
+// Warn logs at [LevelWarn].
+func (l attrLogger) Warn(msg string, args ...any) {
+	l.Logger.Warn(msg, append(l.args[:len(l.args):len(l.args)], args...)...)
+}
+
 // InvocationLogger is the interface that wraps around the methods Debug,
 // Error, Info, Warn and Write. It is used to log to the function host.
 type InvocationLogger interface {
@@ -79,6 +122,13 @@ func newInvocationLogger() invocationLogger {
 	}
 }
 
+// with returns a copy of the invocationLogger that adds the provided
+// attributes (key-value pairs) to every entry. The entries are shared.
+func (l invocationLogger) with(args ...any) invocationLogger {
+	l.l = l.l.With(args...)
+	return l
+}
+
 // Debug logs at [LevelDebug].
 func (l invocationLogger) Debug(msg string, args ...any) {
 	l.mu.Lock()
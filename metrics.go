@@ -0,0 +1,350 @@
+package azfunc
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// defaultDurationBuckets are the default upper bounds (in seconds) of
+	// the buckets of the invocation duration histogram.
+	defaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	// defaultPayloadSizeBuckets are the default upper bounds (in bytes) of
+	// the buckets of the payload size histogram.
+	defaultPayloadSizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+)
+
+// MetricsOptions contains options for the invocation metrics of the
+// FunctionApp.
+type MetricsOptions struct {
+	// DurationBuckets sets the upper bounds (in seconds) of the buckets
+	// of the invocation duration histogram.
+	DurationBuckets []float64
+	// PayloadSizeBuckets sets the upper bounds (in bytes) of the buckets
+	// of the payload size histogram.
+	PayloadSizeBuckets []float64
+}
+
+// MetricsOption is a function that sets options for the invocation
+// metrics of the FunctionApp.
+type MetricsOption func(o *MetricsOptions)
+
+// WithMetrics sets the FunctionApp to record metrics of the invocations
+// of every function (invocations, failures, duration and payload size).
+// The metrics are served in Prometheus text format on /metrics and in
+// expvar format on /debug/vars of the admin listener (WithAdminListener).
+func WithMetrics(options ...MetricsOption) FunctionAppOption {
+	return func(a *functionApp) {
+		opts := MetricsOptions{
+			DurationBuckets:    defaultDurationBuckets,
+			PayloadSizeBuckets: defaultPayloadSizeBuckets,
+		}
+		for _, option := range options {
+			option(&opts)
+		}
+		a.metrics = newMetrics(opts.DurationBuckets, opts.PayloadSizeBuckets)
+	}
+}
+
+// metrics contains the invocation metrics of the functions of a
+// FunctionApp.
+type metrics struct {
+	functions       map[string]*functionMetrics
+	durationBuckets []float64
+	sizeBuckets     []float64
+	mu              sync.RWMutex
+}
+
+// functionMetrics contains the invocation metrics of a function.
+type functionMetrics struct {
+	triggerType string
+	invocations uint64
+	failures    uint64
+	waiting     int64
+	rejected    uint64
+	duration    histogram
+	size        histogram
+}
+
+// newMetrics creates and returns a new metrics with the provided buckets.
+func newMetrics(durationBuckets, sizeBuckets []float64) *metrics {
+	durationBuckets = append([]float64{}, durationBuckets...)
+	sizeBuckets = append([]float64{}, sizeBuckets...)
+	sort.Float64s(durationBuckets)
+	sort.Float64s(sizeBuckets)
+
+	return &metrics{
+		functions:       make(map[string]*functionMetrics),
+		durationBuckets: durationBuckets,
+		sizeBuckets:     sizeBuckets,
+	}
+}
+
+// function returns the metrics of the function, creating them if needed.
+// The lock must be held by the caller.
+func (m *metrics) function(info FunctionInfo) *functionMetrics {
+	fm, ok := m.functions[info.Name]
+	if !ok {
+		fm = &functionMetrics{
+			triggerType: info.TriggerType,
+			duration:    newHistogram(m.durationBuckets),
+			size:        newHistogram(m.sizeBuckets),
+		}
+		m.functions[info.Name] = fm
+	}
+	return fm
+}
+
+// record the invocation of the function with the provided duration and
+// payload size.
+func (m *metrics) record(info FunctionInfo, d time.Duration, size int64, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fm := m.function(info)
+	fm.invocations++
+	if failed {
+		fm.failures++
+	}
+	fm.duration.observe(d.Seconds())
+	if size >= 0 {
+		fm.size.observe(float64(size))
+	}
+}
+
+// wait adds delta to the number of invocations of the function that wait
+// for its concurrency limit.
+func (m *metrics) wait(info FunctionInfo, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.function(info).waiting += delta
+}
+
+// reject records an invocation of the function that was rejected by its
+// concurrency limit.
+func (m *metrics) reject(info FunctionInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.function(info).rejected++
+}
+
+// names returns the names of the functions with metrics in sorted order.
+func (m *metrics) names() []string {
+	names := make([]string, 0, len(m.functions))
+	for name := range m.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writePrometheus writes the metrics in Prometheus text format to w.
+func (m *metrics) writePrometheus(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := m.names()
+	var b strings.Builder
+
+	b.WriteString("# HELP azfunc_invocations_total Total number of invocations.\n")
+	b.WriteString("# TYPE azfunc_invocations_total counter\n")
+	for _, name := range names {
+		fm := m.functions[name]
+		fmt.Fprintf(&b, "azfunc_invocations_total%s %d\n", labels(name, fm.triggerType), fm.invocations)
+	}
+	b.WriteString("# HELP azfunc_failures_total Total number of failed invocations.\n")
+	b.WriteString("# TYPE azfunc_failures_total counter\n")
+	for _, name := range names {
+		fm := m.functions[name]
+		fmt.Fprintf(&b, "azfunc_failures_total%s %d\n", labels(name, fm.triggerType), fm.failures)
+	}
+	b.WriteString("# HELP azfunc_invocations_waiting Number of invocations waiting for the concurrency limit.\n")
+	b.WriteString("# TYPE azfunc_invocations_waiting gauge\n")
+	for _, name := range names {
+		fm := m.functions[name]
+		fmt.Fprintf(&b, "azfunc_invocations_waiting%s %d\n", labels(name, fm.triggerType), fm.waiting)
+	}
+	b.WriteString("# HELP azfunc_invocations_rejected_total Total number of invocations rejected by the concurrency limit.\n")
+	b.WriteString("# TYPE azfunc_invocations_rejected_total counter\n")
+	for _, name := range names {
+		fm := m.functions[name]
+		fmt.Fprintf(&b, "azfunc_invocations_rejected_total%s %d\n", labels(name, fm.triggerType), fm.rejected)
+	}
+	b.WriteString("# HELP azfunc_invocation_duration_seconds Duration of invocations in seconds.\n")
+	b.WriteString("# TYPE azfunc_invocation_duration_seconds histogram\n")
+	for _, name := range names {
+		fm := m.functions[name]
+		fm.duration.writePrometheus(&b, "azfunc_invocation_duration_seconds", name, fm.triggerType)
+	}
+	b.WriteString("# HELP azfunc_payload_size_bytes Size of invocation payloads in bytes.\n")
+	b.WriteString("# TYPE azfunc_payload_size_bytes histogram\n")
+	for _, name := range names {
+		fm := m.functions[name]
+		fm.size.writePrometheus(&b, "azfunc_payload_size_bytes", name, fm.triggerType)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// prometheusHandler returns a handler that serves the metrics in Prometheus
+// text format.
+func (m *metrics) prometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.writePrometheus(w)
+	})
+}
+
+// expvarFunction is the expvar representation of the metrics of a function.
+type expvarFunction struct {
+	TriggerType string          `json:"triggerType"`
+	Invocations uint64          `json:"invocations"`
+	Failures    uint64          `json:"failures"`
+	Waiting     int64           `json:"waiting"`
+	Rejected    uint64          `json:"rejected"`
+	Duration    expvarHistogram `json:"durationSeconds"`
+	PayloadSize expvarHistogram `json:"payloadSizeBytes"`
+}
+
+// expvarHistogram is the expvar representation of a histogram. The buckets
+// are keyed by their upper bound, and are cumulative.
+type expvarHistogram struct {
+	Buckets map[string]uint64 `json:"buckets"`
+	Sum     float64           `json:"sum"`
+	Count   uint64            `json:"count"`
+}
+
+// expvar returns the metrics in their expvar representation.
+func (m *metrics) expvar() map[string]expvarFunction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	functions := make(map[string]expvarFunction, len(m.functions))
+	for name, fm := range m.functions {
+		functions[name] = expvarFunction{
+			TriggerType: fm.triggerType,
+			Invocations: fm.invocations,
+			Failures:    fm.failures,
+			Waiting:     fm.waiting,
+			Rejected:    fm.rejected,
+			Duration:    fm.duration.expvar(),
+			PayloadSize: fm.size.expvar(),
+		}
+	}
+	return functions
+}
+
+// expvarHandler returns a handler that serves the published expvar
+// variables together with the metrics (as "azfunc").
+func (m *metrics) expvarHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := make(map[string]json.RawMessage)
+		expvar.Do(func(kv expvar.KeyValue) {
+			vars[kv.Key] = json.RawMessage(kv.Value.String())
+		})
+		b, err := json.Marshal(m.expvar())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		vars["azfunc"] = b
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(vars)
+	})
+}
+
+// histogram contains observations in buckets with upper bounds.
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// newHistogram creates and returns a new histogram with the provided
+// upper bounds.
+func newHistogram(buckets []float64) histogram {
+	return histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// observe adds the provided value to the histogram.
+func (h *histogram) observe(v float64) {
+	h.sum += v
+	h.count++
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+}
+
+// cumulative returns the cumulative counts of the buckets.
+func (h histogram) cumulative() []uint64 {
+	counts := make([]uint64, len(h.counts))
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		counts[i] = total
+	}
+	return counts
+}
+
+// writePrometheus writes the histogram in Prometheus text format to b.
+func (h histogram) writePrometheus(b *strings.Builder, metric, name, triggerType string) {
+	for i, c := range h.cumulative() {
+		fmt.Fprintf(b, "%s_bucket%s %d\n", metric, labels(name, triggerType, "le", formatFloat(h.buckets[i])), c)
+	}
+	fmt.Fprintf(b, "%s_bucket%s %d\n", metric, labels(name, triggerType, "le", "+Inf"), h.count)
+	fmt.Fprintf(b, "%s_sum%s %s\n", metric, labels(name, triggerType), formatFloat(h.sum))
+	fmt.Fprintf(b, "%s_count%s %d\n", metric, labels(name, triggerType), h.count)
+}
+
+// expvar returns the expvar representation of the histogram.
+func (h histogram) expvar() expvarHistogram {
+	buckets := make(map[string]uint64, len(h.buckets)+1)
+	for i, c := range h.cumulative() {
+		buckets[formatFloat(h.buckets[i])] = c
+	}
+	buckets["+Inf"] = h.count
+	return expvarHistogram{
+		Buckets: buckets,
+		Sum:     h.sum,
+		Count:   h.count,
+	}
+}
+
+// labels returns the Prometheus labels of a function, with the additional
+// labels (key-value pairs).
+func labels(name, triggerType string, kv ...string) string {
+	var b strings.Builder
+	b.WriteString(`{function="` + escapeLabel(name) + `",trigger="` + escapeLabel(triggerType) + `"`)
+	for i := 0; i+1 < len(kv); i += 2 {
+		b.WriteString(`,` + kv[i] + `="` + escapeLabel(kv[i+1]) + `"`)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// labelReplacer escapes label values of the Prometheus text format.
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes the provided label value.
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+// formatFloat formats the provided float for the Prometheus text format.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
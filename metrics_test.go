@@ -0,0 +1,105 @@
+package azfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetrics_WritePrometheus(t *testing.T) {
+	m := newMetrics([]float64{0.1, 1}, []float64{100})
+	info := FunctionInfo{Name: "hello-queue", TriggerType: "queueTrigger"}
+	m.record(info, 50*time.Millisecond, 10, false)
+	m.record(info, 500*time.Millisecond, 200, true)
+	m.wait(info, 1)
+	m.reject(info)
+
+	want := `# HELP azfunc_invocations_total Total number of invocations.
+# TYPE azfunc_invocations_total counter
+azfunc_invocations_total{function="hello-queue",trigger="queueTrigger"} 2
+# HELP azfunc_failures_total Total number of failed invocations.
+# TYPE azfunc_failures_total counter
+azfunc_failures_total{function="hello-queue",trigger="queueTrigger"} 1
+# HELP azfunc_invocations_waiting Number of invocations waiting for the concurrency limit.
+# TYPE azfunc_invocations_waiting gauge
+azfunc_invocations_waiting{function="hello-queue",trigger="queueTrigger"} 1
+# HELP azfunc_invocations_rejected_total Total number of invocations rejected by the concurrency limit.
+# TYPE azfunc_invocations_rejected_total counter
+azfunc_invocations_rejected_total{function="hello-queue",trigger="queueTrigger"} 1
+# HELP azfunc_invocation_duration_seconds Duration of invocations in seconds.
+# TYPE azfunc_invocation_duration_seconds histogram
+azfunc_invocation_duration_seconds_bucket{function="hello-queue",trigger="queueTrigger",le="0.1"} 1
+azfunc_invocation_duration_seconds_bucket{function="hello-queue",trigger="queueTrigger",le="1"} 2
+azfunc_invocation_duration_seconds_bucket{function="hello-queue",trigger="queueTrigger",le="+Inf"} 2
+azfunc_invocation_duration_seconds_sum{function="hello-queue",trigger="queueTrigger"} 0.55
+azfunc_invocation_duration_seconds_count{function="hello-queue",trigger="queueTrigger"} 2
+# HELP azfunc_payload_size_bytes Size of invocation payloads in bytes.
+# TYPE azfunc_payload_size_bytes histogram
+azfunc_payload_size_bytes_bucket{function="hello-queue",trigger="queueTrigger",le="100"} 1
+azfunc_payload_size_bytes_bucket{function="hello-queue",trigger="queueTrigger",le="+Inf"} 2
+azfunc_payload_size_bytes_sum{function="hello-queue",trigger="queueTrigger"} 210
+azfunc_payload_size_bytes_count{function="hello-queue",trigger="queueTrigger"} 2
+`
+	var b strings.Builder
+	if err := m.writePrometheus(&b); err != nil {
+		t.Fatalf("writePrometheus() = unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writePrometheus() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	app := NewFunctionApp(WithDisableLogging(), WithMetrics())
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		if strings.Contains(string(trigger.Data), "fail") {
+			return errors.New("error")
+		}
+		return nil
+	}))
+
+	for _, body := range []string{
+		`{"Data":{"queue":"hello"},"Metadata":{}}`,
+		`{"Data":{"queue":"fail message"},"Metadata":{}}`,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(body)))
+		app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	app.adminHandler().ServeHTTP(w, r)
+
+	var vars struct {
+		Azfunc map[string]expvarFunction `json:"azfunc"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("expvarHandler() = unexpected error: %v", err)
+	}
+
+	got := vars.Azfunc["hello-queue"]
+	if got.TriggerType != "queueTrigger" || got.Invocations != 2 || got.Failures != 1 {
+		t.Errorf("WithMetrics() = unexpected result, want: queueTrigger, 2 invocations, 1 failure, got: %s, %d invocations, %d failures\n", got.TriggerType, got.Invocations, got.Failures)
+	}
+	if got.PayloadSize.Count != 2 || got.Duration.Count != 2 {
+		t.Errorf("WithMetrics() = unexpected histogram counts, want: 2, got: %d, %d\n", got.Duration.Count, got.PayloadSize.Count)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	app.adminHandler().ServeHTTP(w, r)
+
+	if !strings.Contains(w.Body.String(), `azfunc_invocations_total{function="hello-queue",trigger="queueTrigger"} 2`) {
+		t.Errorf("prometheusHandler() = unexpected result:\n%s\n", w.Body.String())
+	}
+}
@@ -0,0 +1,62 @@
+package azfunc
+
+// FunctionInfo contains information about the function that is run.
+type FunctionInfo struct {
+	// Name is the name of the function.
+	Name string
+	// TriggerType is the type of the trigger, as set in function.json
+	// (e.g. "httpTrigger", "queueTrigger").
+	TriggerType string
+	// TriggerName is the name of the trigger binding.
+	TriggerName string
+}
+
+// NextFunc runs the next middleware in the chain, or the function
+// if it is the last middleware.
+type NextFunc func(ctx *Context) error
+
+// Middleware is a function that wraps around the run of a function. It
+// receives the *Context, information about the function and the next
+// function in the chain. A middleware that does not call next stops
+// the function from being run.
+type Middleware func(ctx *Context, info FunctionInfo, next NextFunc) error
+
+// WithMiddleware adds the provided middleware to all functions of the
+// FunctionApp. Middleware set on the FunctionApp is run before middleware
+// set on the function, in the order it was added.
+func WithMiddleware(middleware ...Middleware) FunctionAppOption {
+	return func(a *functionApp) {
+		a.middleware = append(a.middleware, middleware...)
+	}
+}
+
+// WithFunctionMiddleware adds the provided middleware to the function. It
+// is run after the middleware set on the FunctionApp, in the order it
+// was added.
+func WithFunctionMiddleware(middleware ...Middleware) FunctionOption {
+	return func(f *function) {
+		f.middleware = append(f.middleware, middleware...)
+	}
+}
+
+// chain returns a NextFunc that runs the provided middleware in order,
+// followed by fn.
+func chain(info FunctionInfo, fn NextFunc, middleware ...Middleware) NextFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw, next := middleware[i], fn
+		fn = func(ctx *Context) error {
+			return mw(ctx, info, next)
+		}
+	}
+	return fn
+}
+
+// info returns the FunctionInfo of the function.
+func (f function) info() FunctionInfo {
+	info := FunctionInfo{Name: f.name}
+	if f.trigger != nil {
+		b := f.trigger.binding()
+		info.TriggerType, info.TriggerName = b.Type, b.Name
+	}
+	return info
+}
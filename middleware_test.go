@@ -0,0 +1,72 @@
+package azfunc
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got []string
+	record := func(name string) Middleware {
+		return func(ctx *Context, info FunctionInfo, next NextFunc) error {
+			got = append(got, name+" before "+info.Name+" "+info.TriggerType+" "+info.TriggerName)
+			err := next(ctx)
+			got = append(got, name+" after")
+			return err
+		}
+	}
+
+	app := NewFunctionApp(WithDisableLogging(), WithMiddleware(record("app1"), record("app2")))
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		got = append(got, "function")
+		return nil
+	}), WithFunctionMiddleware(record("function1")))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+	app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+	want := []string{
+		"app1 before hello-queue queueTrigger queue",
+		"app2 before hello-queue queueTrigger queue",
+		"function1 before hello-queue queueTrigger queue",
+		"function",
+		"function1 after",
+		"app2 after",
+		"app1 after",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Middleware = unexpected result (-want +got)\n%s\n", diff)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("Middleware = unexpected status code, want: %d, got: %d\n", http.StatusOK, w.Code)
+	}
+}
+
+func TestMiddleware_Stop(t *testing.T) {
+	var called bool
+	app := NewFunctionApp(WithDisableLogging(), WithMiddleware(func(ctx *Context, info FunctionInfo, next NextFunc) error {
+		return errors.New("unauthorized")
+	}))
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		called = true
+		return nil
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+	app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+	if called {
+		t.Errorf("Middleware = unexpected result, function should not be called\n")
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Middleware = unexpected status code, want: %d, got: %d\n", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,116 @@
+// Package otel provides OpenTelemetry tracing of the invocations of an
+// azfunc.FunctionApp.
+package otel
+
+import (
+	"context"
+
+	"github.com/KarlGW/azfunc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the name of the tracer used to start the spans
+	// of invocations.
+	tracerName = "github.com/KarlGW/azfunc"
+)
+
+// Tracer starts an OpenTelemetry span for every invocation and injects
+// the trace context of the invocation. It implements azfunc.Tracer.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// TracerOptions contains options for a Tracer.
+type TracerOptions struct {
+	// TracerProvider sets the provider of the tracer used to start the
+	// span of every invocation. Defaults to the global TracerProvider.
+	TracerProvider trace.TracerProvider
+	// Propagator sets the propagator used to extract and inject trace
+	// context. Defaults to W3C trace context.
+	Propagator propagation.TextMapPropagator
+}
+
+// TracerOption is a function that sets options for a Tracer.
+type TracerOption func(o *TracerOptions)
+
+// NewTracer creates a new Tracer.
+func NewTracer(options ...TracerOption) *Tracer {
+	opts := TracerOptions{
+		TracerProvider: otel.GetTracerProvider(),
+		Propagator:     propagation.TraceContext{},
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	return &Tracer{
+		tracer:     opts.TracerProvider.Tracer(tracerName),
+		propagator: opts.Propagator,
+	}
+}
+
+// WithTracing enables OpenTelemetry tracing for the FunctionApp. A span is
+// started for every invocation, with the trace context of the HTTP trigger
+// headers, the Service Bus application properties (traceparent, tracestate
+// and Diagnostic-Id) or the message (traceparent and tracestate) as parent.
+func WithTracing(options ...TracerOption) azfunc.FunctionAppOption {
+	return azfunc.WithTracer(NewTracer(options...))
+}
+
+// Start starts the span of an invocation, with the trace context of the
+// provided carrier as parent.
+func (t *Tracer) Start(ctx context.Context, carrier map[string]string, info azfunc.SpanInfo) (context.Context, azfunc.Span) {
+	if carrier != nil {
+		ctx = t.propagator.Extract(ctx, propagation.MapCarrier(carrier))
+	}
+
+	faasTrigger, kind := "other", trace.SpanKindInternal
+	switch info.TriggerType {
+	case "httpTrigger":
+		faasTrigger, kind = "http", trace.SpanKindServer
+	case "timerTrigger":
+		faasTrigger = "timer"
+	case "queueTrigger", "serviceBusTrigger", "eventHubTrigger", "eventGridTrigger", "kafkaTrigger":
+		faasTrigger, kind = "pubsub", trace.SpanKindConsumer
+	case "blobTrigger", "cosmosDBTrigger":
+		faasTrigger = "datasource"
+	}
+
+	ctx, s := t.tracer.Start(ctx, info.Name,
+		trace.WithSpanKind(kind),
+		trace.WithTimestamp(info.StartTime),
+		trace.WithAttributes(
+			attribute.String("faas.name", info.Name),
+			attribute.String("faas.trigger", faasTrigger),
+			attribute.String("faas.invocation_id", info.InvocationID),
+		),
+	)
+	return ctx, span{Span: s}
+}
+
+// Inject returns the trace context of the provided context as key-value
+// pairs (traceparent and tracestate with the default propagator).
+func (t *Tracer) Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	t.propagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// span wraps around a trace.Span to implement azfunc.Span.
+type span struct {
+	trace.Span
+}
+
+// End sets the status of the span from the provided error and ends it.
+func (s span) End(err error) {
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
@@ -0,0 +1,166 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer_Start(t *testing.T) {
+	var tests = []struct {
+		name    string
+		carrier map[string]string
+		info    azfunc.SpanInfo
+		err     error
+		want    struct {
+			traceID    string
+			parentID   string
+			kind       trace.SpanKind
+			status     codes.Code
+			attributes []attribute.KeyValue
+		}
+	}{
+		{
+			name:    "HTTP trigger with traceparent",
+			carrier: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			info: azfunc.SpanInfo{
+				FunctionInfo: azfunc.FunctionInfo{Name: "hello", TriggerType: "httpTrigger", TriggerName: "req"},
+				InvocationID: "1",
+			},
+			want: struct {
+				traceID    string
+				parentID   string
+				kind       trace.SpanKind
+				status     codes.Code
+				attributes []attribute.KeyValue
+			}{
+				traceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+				parentID: "00f067aa0ba902b7",
+				kind:     trace.SpanKindServer,
+				status:   codes.Unset,
+				attributes: []attribute.KeyValue{
+					attribute.String("faas.name", "hello"),
+					attribute.String("faas.trigger", "http"),
+					attribute.String("faas.invocation_id", "1"),
+				},
+			},
+		},
+		{
+			name:    "Queue trigger with traceparent and error",
+			carrier: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			info: azfunc.SpanInfo{
+				FunctionInfo: azfunc.FunctionInfo{Name: "hello", TriggerType: "queueTrigger", TriggerName: "queue"},
+				InvocationID: "1",
+			},
+			err: errors.New("error"),
+			want: struct {
+				traceID    string
+				parentID   string
+				kind       trace.SpanKind
+				status     codes.Code
+				attributes []attribute.KeyValue
+			}{
+				traceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+				parentID: "00f067aa0ba902b7",
+				kind:     trace.SpanKindConsumer,
+				status:   codes.Error,
+				attributes: []attribute.KeyValue{
+					attribute.String("faas.name", "hello"),
+					attribute.String("faas.trigger", "pubsub"),
+					attribute.String("faas.invocation_id", "1"),
+				},
+			},
+		},
+		{
+			name: "Timer trigger without trace context",
+			info: azfunc.SpanInfo{
+				FunctionInfo: azfunc.FunctionInfo{Name: "hello", TriggerType: "timerTrigger", TriggerName: "timer"},
+				InvocationID: "1",
+			},
+			want: struct {
+				traceID    string
+				parentID   string
+				kind       trace.SpanKind
+				status     codes.Code
+				attributes []attribute.KeyValue
+			}{
+				parentID: "0000000000000000",
+				kind:     trace.SpanKindInternal,
+				status:   codes.Unset,
+				attributes: []attribute.KeyValue{
+					attribute.String("faas.name", "hello"),
+					attribute.String("faas.trigger", "timer"),
+					attribute.String("faas.invocation_id", "1"),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			tracer := NewTracer(func(o *TracerOptions) {
+				o.TracerProvider = tp
+			})
+
+			test.info.StartTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			ctx, s := tracer.Start(context.Background(), test.carrier, test.info)
+			s.End(test.err)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Start() = unexpected number of spans, want: 1, got: %d\n", len(spans))
+			}
+			got := spans[0]
+
+			if len(test.want.traceID) > 0 && test.want.traceID != got.SpanContext().TraceID().String() {
+				t.Errorf("Start() = unexpected trace ID, want: %s, got: %s\n", test.want.traceID, got.SpanContext().TraceID())
+			}
+			if test.want.parentID != got.Parent().SpanID().String() {
+				t.Errorf("Start() = unexpected parent span ID, want: %s, got: %s\n", test.want.parentID, got.Parent().SpanID())
+			}
+			if test.want.kind != got.SpanKind() {
+				t.Errorf("Start() = unexpected span kind, want: %s, got: %s\n", test.want.kind, got.SpanKind())
+			}
+			if test.want.status != got.Status().Code {
+				t.Errorf("Start() = unexpected status, want: %s, got: %s\n", test.want.status, got.Status().Code)
+			}
+			if !test.info.StartTime.Equal(got.StartTime()) {
+				t.Errorf("Start() = unexpected start time, want: %v, got: %v\n", test.info.StartTime, got.StartTime())
+			}
+			if diff := cmp.Diff(test.want.attributes, got.Attributes(), cmp.AllowUnexported(attribute.Value{})); diff != "" {
+				t.Errorf("Start() = unexpected attributes (-want +got)\n%s\n", diff)
+			}
+			if !trace.SpanContextFromContext(ctx).Equal(got.SpanContext()) {
+				t.Errorf("Start() = unexpected span context, want: %v, got: %v\n", got.SpanContext(), trace.SpanContextFromContext(ctx))
+			}
+		})
+	}
+}
+
+func TestTracer_Inject(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tracer := NewTracer()
+	ctx := tracer.propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceparent})
+
+	got := tracer.Inject(ctx)
+	if diff := cmp.Diff(map[string]string{"traceparent": traceparent}, got); diff != "" {
+		t.Errorf("Inject() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	got = tracer.Inject(context.Background())
+	if diff := cmp.Diff(map[string]string{}, got); diff != "" {
+		t.Errorf("Inject() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
@@ -0,0 +1,28 @@
+package output
+
+// Binding contains the configuration of an output binding as
+// set in function.json.
+type Binding struct {
+	// Type is the type of the binding.
+	Type string
+	// Connection is the name of the setting that contains the
+	// connection of the binding.
+	Connection string
+	// QueueName is the name of the queue to send messages to.
+	QueueName string
+	// TopicName is the name of the topic to send messages to.
+	TopicName string
+	// TopicEndpointURI is the name of the setting that contains the
+	// endpoint of the Event Grid topic.
+	TopicEndpointURI string
+	// TopicKeySetting is the name of the setting that contains the
+	// key of the Event Grid topic.
+	TopicKeySetting string
+	// BrokerList is the name of the setting that contains the list
+	// of Kafka brokers.
+	BrokerList string
+	// Topic is the Kafka topic to send records to.
+	Topic string
+	// Properties contains additional properties of the binding.
+	Properties map[string]any
+}
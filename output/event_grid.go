@@ -6,8 +6,9 @@ import (
 
 // EventGrid represents an Event Grid output binding.
 type EventGrid struct {
-	name string
-	data data.Raw
+	name    string
+	data    data.Raw
+	binding Binding
 }
 
 // EventGridOptions contains options for an Event Grid output binding.
@@ -16,6 +17,12 @@ type EventGridOptions struct {
 	Name string
 	// Data sets the data of the binding.
 	Data data.Raw
+	// TopicEndpointURI sets the name of the setting that contains the
+	// endpoint of the topic (function.json).
+	TopicEndpointURI string
+	// TopicKeySetting sets the name of the setting that contains the
+	// key of the topic (function.json).
+	TopicKeySetting string
 }
 
 // EventGridOption is a function that sets options on an Event Grid output binding.
@@ -31,6 +38,11 @@ func (o EventGrid) Name() string {
 	return o.name
 }
 
+// Binding returns the configuration of the binding.
+func (o EventGrid) Binding() Binding {
+	return o.binding
+}
+
 // Write data to the binding.
 func (o *EventGrid) Write(d []byte) (int, error) {
 	o.data = data.Raw(d)
@@ -46,5 +58,10 @@ func NewEventGrid(name string, options ...EventGridOption) *EventGrid {
 	return &EventGrid{
 		name: name,
 		data: opts.Data,
+		binding: Binding{
+			Type:             "eventGrid",
+			TopicEndpointURI: opts.TopicEndpointURI,
+			TopicKeySetting:  opts.TopicKeySetting,
+		},
 	}
 }
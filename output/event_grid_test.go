@@ -32,8 +32,9 @@ func TestNewEventGrid(t *testing.T) {
 				options: nil,
 			},
 			want: &EventGrid{
-				name: "event",
-				data: nil,
+				name:    "event",
+				data:    nil,
+				binding: Binding{Type: "eventGrid"},
 			},
 		},
 		{
@@ -56,8 +57,9 @@ func TestNewEventGrid(t *testing.T) {
 				},
 			},
 			want: &EventGrid{
-				name: "event",
-				data: data.Raw(`{"time":"2024-01-01T00:00:00Z","data":{"message":"hello"},"specversion":"1.0","type":"type","source":"source","id":"12345"}`),
+				name:    "event",
+				data:    data.Raw(`{"time":"2024-01-01T00:00:00Z","data":{"message":"hello"},"specversion":"1.0","type":"type","source":"source","id":"12345"}`),
+				binding: Binding{Type: "eventGrid"},
 			},
 		},
 	}
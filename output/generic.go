@@ -6,8 +6,9 @@ import "github.com/KarlGW/azfunc/data"
 // all bindings that are not HTTP output bindings share the
 // same data structure.
 type Generic struct {
-	name string
-	data data.Raw
+	name    string
+	data    data.Raw
+	binding Binding
 }
 
 // GenericOptions contains options for a Generic output binding.
@@ -16,6 +17,11 @@ type GenericOptions struct {
 	Name string
 	// Data sets the data of the binding.
 	Data data.Raw
+	// Type sets the type of the binding (function.json).
+	Type string
+	// Properties sets additional properties of the binding
+	// (function.json).
+	Properties map[string]any
 }
 
 // GenericOption is a function that sets options on a Generic output binding
@@ -31,6 +37,11 @@ func (o Generic) Name() string {
 	return o.name
 }
 
+// Binding returns the configuration of the binding.
+func (o Generic) Binding() Binding {
+	return o.binding
+}
+
 // Write data to the binding.
 func (o *Generic) Write(d []byte) (int, error) {
 	o.data = data.Raw(d)
@@ -46,5 +57,9 @@ func NewGeneric(name string, options ...GenericOption) *Generic {
 	return &Generic{
 		name: name,
 		data: opts.Data,
+		binding: Binding{
+			Type:       opts.Type,
+			Properties: opts.Properties,
+		},
 	}
 }
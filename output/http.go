@@ -56,6 +56,11 @@ func (o HTTP) Data() data.Raw {
 	return o.body
 }
 
+// StatusCode returns the status code of the binding.
+func (o HTTP) StatusCode() int {
+	return o.statusCode
+}
+
 // Name returns the name of the binding. In case of an HTTP binding
 // it is always "res".
 func (o HTTP) Name() string {
@@ -65,6 +70,11 @@ func (o HTTP) Name() string {
 	return "res"
 }
 
+// Binding returns the configuration of the binding.
+func (o HTTP) Binding() Binding {
+	return Binding{Type: "http"}
+}
+
 // Write the provided data to the body of the HTTP bindings.
 func (o *HTTP) Write(d []byte) (int, error) {
 	o.body = data.Raw(d)
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"encoding/json"
+
+	"github.com/KarlGW/azfunc/data"
+)
+
+// Kafka represents a Kafka output binding.
+type Kafka struct {
+	name    string
+	data    data.Raw
+	binding Binding
+}
+
+// KafkaOptions contains options for a Kafka output binding.
+type KafkaOptions struct {
+	// Name sets the name of the binding.
+	Name string
+	// Data sets the data of the binding.
+	Data data.Raw
+	// BrokerList sets the name of the setting that contains the
+	// list of brokers (function.json).
+	BrokerList string
+	// Topic sets the topic to send records to (function.json).
+	Topic string
+}
+
+// KafkaOption is a function that sets options on a Kafka output binding.
+type KafkaOption func(o *KafkaOptions)
+
+// KafkaRecord represents a record to be written to a Kafka
+// output binding.
+type KafkaRecord struct {
+	Key     string        `json:"Key,omitempty"`
+	Value   data.Raw      `json:"Value"`
+	Headers []KafkaHeader `json:"Headers,omitempty"`
+}
+
+// KafkaHeader represents a header of a Kafka record.
+type KafkaHeader struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// Data returns the data of the binding.
+func (o Kafka) Data() data.Raw {
+	return o.data
+}
+
+// Name returns the name of the binding.
+func (o Kafka) Name() string {
+	return o.name
+}
+
+// Binding returns the configuration of the binding.
+func (o Kafka) Binding() Binding {
+	return o.binding
+}
+
+// Write data to the binding. The data is written as the value
+// of a single record without key and headers.
+func (o *Kafka) Write(d []byte) (int, error) {
+	o.data = data.Raw(d)
+	return len(o.data), nil
+}
+
+// WriteRecords writes the provided records to the binding. Keys and headers
+// are only sent when records are written with this method. Nothing is
+// written if no records are provided.
+func (o *Kafka) WriteRecords(records ...KafkaRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var v any
+	if len(records) == 1 {
+		v = records[0]
+	} else {
+		v = records
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	o.data = data.Raw(b)
+	return nil
+}
+
+// NewKafka creates a new Kafka output binding.
+func NewKafka(name string, options ...KafkaOption) *Kafka {
+	opts := KafkaOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	return &Kafka{
+		name: name,
+		data: opts.Data,
+		binding: Binding{
+			Type:       "kafka",
+			BrokerList: opts.BrokerList,
+			Topic:      opts.Topic,
+		},
+	}
+}
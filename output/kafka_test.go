@@ -0,0 +1,161 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewKafka(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			name    string
+			options []KafkaOption
+		}
+		want *Kafka
+	}{
+		{
+			name: "defaults",
+			input: struct {
+				name    string
+				options []KafkaOption
+			}{
+				name:    "kafka",
+				options: nil,
+			},
+			want: &Kafka{
+				name:    "kafka",
+				data:    nil,
+				binding: Binding{Type: "kafka"},
+			},
+		},
+		{
+			name: "with options",
+			input: struct {
+				name    string
+				options []KafkaOption
+			}{
+				name: "kafka",
+				options: []KafkaOption{
+					func(o *KafkaOptions) {
+						o.Data = data.Raw(`{"message":"hello"}`)
+					},
+				},
+			},
+			want: &Kafka{
+				name:    "kafka",
+				data:    data.Raw(`{"message":"hello"}`),
+				binding: Binding{Type: "kafka"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewKafka(test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Kafka{})); diff != "" {
+				t.Errorf("NewKafka() = unexpected (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestKafka_Write(t *testing.T) {
+	t.Run("Write", func(t *testing.T) {
+		got := &Kafka{}
+		got.Write([]byte(`{"message":"hello"}`))
+		want := &Kafka{data: data.Raw(`{"message":"hello"}`)}
+
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(Kafka{})); diff != "" {
+			t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+		}
+	})
+}
+
+func TestKafka_WriteRecords(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []KafkaRecord
+		want  *Kafka
+	}{
+		{
+			name: "single record",
+			input: []KafkaRecord{
+				{
+					Key:   "key1",
+					Value: data.Raw(`{"message":"hello"}`),
+					Headers: []KafkaHeader{
+						{Key: "source", Value: "azfunc"},
+					},
+				},
+			},
+			want: &Kafka{
+				data: data.Raw(`{"Key":"key1","Value":"{\"message\":\"hello\"}","Headers":[{"Key":"source","Value":"azfunc"}]}`),
+			},
+		},
+		{
+			name: "multiple records",
+			input: []KafkaRecord{
+				{
+					Key:   "key1",
+					Value: data.Raw(`hello`),
+				},
+				{
+					Value: data.Raw(`world`),
+				},
+			},
+			want: &Kafka{
+				data: data.Raw(`[{"Key":"key1","Value":"hello"},{"Value":"world"}]`),
+			},
+		},
+		{
+			name: "no records",
+			want: &Kafka{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := &Kafka{}
+			if err := got.WriteRecords(test.input...); err != nil {
+				t.Fatalf("WriteRecords() = unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Kafka{})); diff != "" {
+				t.Errorf("WriteRecords() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestKafka_Name(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input *Kafka
+		want  string
+	}{
+		{
+			name:  "default",
+			input: &Kafka{},
+			want:  "",
+		},
+		{
+			name:  "with name",
+			input: &Kafka{name: "kafka"},
+			want:  "kafka",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.Name()
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Kafka{})); diff != "" {
+				t.Errorf("Name() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
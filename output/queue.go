@@ -4,8 +4,9 @@ import "github.com/KarlGW/azfunc/data"
 
 // Queue represents a Queue Storage output binding.
 type Queue struct {
-	name string
-	data data.Raw
+	name    string
+	data    data.Raw
+	binding Binding
 }
 
 // QueueOptions contains options for a Queue Storage output binding.
@@ -14,6 +15,12 @@ type QueueOptions struct {
 	Name string
 	// Data sets the data of the binding.
 	Data data.Raw
+	// QueueName sets the name of the queue to send messages to
+	// (function.json).
+	QueueName string
+	// Connection sets the name of the setting that contains the
+	// connection to the storage account (function.json).
+	Connection string
 }
 
 // QueueOption is a function that sets options on a Queue Storage output binding.
@@ -29,6 +36,11 @@ func (o Queue) Name() string {
 	return o.name
 }
 
+// Binding returns the configuration of the binding.
+func (o Queue) Binding() Binding {
+	return o.binding
+}
+
 // Write data to the binding.
 func (o *Queue) Write(d []byte) (int, error) {
 	o.data = data.Raw(d)
@@ -44,5 +56,10 @@ func NewQueue(name string, options ...QueueOption) *Queue {
 	return &Queue{
 		name: name,
 		data: opts.Data,
+		binding: Binding{
+			Type:       "queue",
+			QueueName:  opts.QueueName,
+			Connection: opts.Connection,
+		},
 	}
 }
@@ -26,8 +26,9 @@ func TestNewQueue(t *testing.T) {
 				options: nil,
 			},
 			want: &Queue{
-				name: "queue",
-				data: nil,
+				name:    "queue",
+				data:    nil,
+				binding: Binding{Type: "queue"},
 			},
 		},
 		{
@@ -40,12 +41,19 @@ func TestNewQueue(t *testing.T) {
 				options: []QueueOption{
 					func(o *QueueOptions) {
 						o.Data = data.Raw(`{"message":"hello"}`)
+						o.QueueName = "items"
+						o.Connection = "AzureWebJobsStorage"
 					},
 				},
 			},
 			want: &Queue{
 				name: "queue",
 				data: data.Raw(`{"message":"hello"}`),
+				binding: Binding{
+					Type:       "queue",
+					QueueName:  "items",
+					Connection: "AzureWebJobsStorage",
+				},
 			},
 		},
 	}
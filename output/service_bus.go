@@ -4,8 +4,9 @@ import "github.com/KarlGW/azfunc/data"
 
 // ServiceBus represents a service bus output binding.
 type ServiceBus struct {
-	name string
-	data data.Raw
+	name    string
+	data    data.Raw
+	binding Binding
 }
 
 // ServiceBusOptions contains options for a ServiceBus output binding.
@@ -14,6 +15,15 @@ type ServiceBusOptions struct {
 	Name string
 	// Data sets the data of the binding.
 	Data data.Raw
+	// QueueName sets the name of the queue to send messages to
+	// (function.json).
+	QueueName string
+	// TopicName sets the name of the topic to send messages to
+	// (function.json).
+	TopicName string
+	// Connection sets the name of the setting that contains the
+	// connection to the Service Bus namespace (function.json).
+	Connection string
 }
 
 // ServiceBusOption is a function that sets options on a ServiceBus output binding.
@@ -29,6 +39,11 @@ func (o ServiceBus) Name() string {
 	return o.name
 }
 
+// Binding returns the configuration of the binding.
+func (o ServiceBus) Binding() Binding {
+	return o.binding
+}
+
 // Write data to the binding.
 func (o *ServiceBus) Write(d []byte) (int, error) {
 	o.data = data.Raw(d)
@@ -44,5 +59,11 @@ func NewServiceBus(name string, options ...ServiceBusOption) *ServiceBus {
 	return &ServiceBus{
 		name: name,
 		data: opts.Data,
+		binding: Binding{
+			Type:       "serviceBus",
+			QueueName:  opts.QueueName,
+			TopicName:  opts.TopicName,
+			Connection: opts.Connection,
+		},
 	}
 }
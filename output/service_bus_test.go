@@ -26,8 +26,9 @@ func TestNewServiceBus(t *testing.T) {
 				options: nil,
 			},
 			want: &ServiceBus{
-				name: "queue",
-				data: nil,
+				name:    "queue",
+				data:    nil,
+				binding: Binding{Type: "serviceBus"},
 			},
 		},
 		{
@@ -44,8 +45,9 @@ func TestNewServiceBus(t *testing.T) {
 				},
 			},
 			want: &ServiceBus{
-				name: "queue",
-				data: data.Raw(`{"message":"hello"}`),
+				name:    "queue",
+				data:    data.Raw(`{"message":"hello"}`),
+				binding: Binding{Type: "serviceBus"},
 			},
 		},
 	}
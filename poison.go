@@ -0,0 +1,178 @@
+package azfunc
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+)
+
+const (
+	// defaultQueueMaxDequeueCount is the default number of times a Queue
+	// Storage message is dequeued before it is routed to the poison output.
+	// Same as the default maxDequeueCount of the function host.
+	defaultQueueMaxDequeueCount = 5
+	// defaultServiceBusMaxDeliveryCount is the default number of times a
+	// Service Bus message is delivered before it is routed to the poison
+	// output. Same as the default max delivery count of Service Bus.
+	defaultServiceBusMaxDeliveryCount = 10
+)
+
+// PoisonMessage is the message written to the poison output binding. It
+// contains the original payload of the invocation and details about the
+// error.
+type PoisonMessage struct {
+	// FunctionName is the name of the function.
+	FunctionName string `json:"functionName"`
+	// InvocationID is the ID of the invocation that failed.
+	InvocationID string `json:"invocationId"`
+	// TriggerType is the type of the trigger of the function.
+	TriggerType string `json:"triggerType"`
+	// MessageID is the ID of the message, if any.
+	MessageID string `json:"messageId,omitempty"`
+	// DeliveryCount is the number of times the message has been delivered
+	// (dequeued).
+	DeliveryCount int `json:"deliveryCount"`
+	// Error is the message of the error.
+	Error string `json:"error"`
+	// Permanent is true if the error is permanent.
+	Permanent bool `json:"permanent"`
+	// Time is the time the message was routed to the poison output.
+	Time time.Time `json:"time"`
+	// Data is the original payload.
+	Data data.Raw `json:"data"`
+}
+
+// PoisonOptions contains options for the poison output of a function.
+type PoisonOptions struct {
+	// MaxDeliveryCount sets the delivery (dequeue) count at which a message
+	// that fails is routed to the poison output. Defaults to 5 for Queue
+	// Storage triggers and 10 for Service Bus triggers.
+	MaxDeliveryCount int
+}
+
+// PoisonOption is a function that sets options for the poison output of
+// a function.
+type PoisonOption func(o *PoisonOptions)
+
+// poisonPolicy contains the poison output of a function and when messages
+// are routed to it.
+type poisonPolicy struct {
+	output           outputable
+	maxDeliveryCount int
+}
+
+// WithPoisonOutput sets the provided output binding (e.g. a queue with
+// output.NewQueue) as the poison output of the function. When an invocation
+// fails with a permanent error (BadRequest or Permanent), or fails when the
+// delivery count of a Queue Storage or Service Bus message has reached the
+// maximum, the original payload and the error is written to the poison
+// output as a PoisonMessage. The invocation is completed successfully, so
+// that the function host does not retry it.
+func WithPoisonOutput(output outputable, options ...PoisonOption) FunctionOption {
+	return func(f *function) {
+		if output == nil {
+			return
+		}
+		opts := PoisonOptions{}
+		for _, option := range options {
+			option(&opts)
+		}
+		f.poison = &poisonPolicy{
+			output:           output,
+			maxDeliveryCount: opts.MaxDeliveryCount,
+		}
+	}
+}
+
+// route returns outputs with the PoisonMessage of the failed invocation
+// written to the poison output, if it should be routed to it. The invocation
+// logs are kept.
+func (p poisonPolicy) route(ctx *Context, info FunctionInfo, body []byte, err error) (*outputs, bool) {
+	var payload struct {
+		Data     map[string]data.Raw
+		Metadata map[string]any
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, false
+	}
+
+	permanent := IsPermanent(err)
+	count := deliveryCount(info.TriggerType, payload.Metadata)
+	if !permanent {
+		max := p.maxDeliveryCount
+		if max <= 0 {
+			max = defaultMaxDeliveryCount(info.TriggerType)
+		}
+		if max <= 0 || count < max {
+			return nil, false
+		}
+	}
+
+	msg, merr := json.Marshal(PoisonMessage{
+		FunctionName:  info.Name,
+		InvocationID:  ctx.InvocationID(),
+		TriggerType:   info.TriggerType,
+		MessageID:     metadataString(payload.Metadata, "MessageId", "Id"),
+		DeliveryCount: count,
+		Error:         err.Error(),
+		Permanent:     permanent,
+		Time:          time.Now().UTC(),
+		Data:          payload.Data[info.TriggerName],
+	})
+	if merr != nil {
+		return nil, false
+	}
+
+	poison := cloneOutput(p.output)
+	poison.Write(msg)
+	ctx.Outputs.Log().Warn("Message routed to poison output.", "output", poison.Name(), "deliveryCount", count, "error", err.Error())
+
+	o := &outputs{log: ctx.Outputs.log}
+	o.Add(poison)
+	return o, true
+}
+
+// defaultMaxDeliveryCount returns the default maximum delivery count of
+// the provided trigger type. Returns 0 for triggers without a delivery
+// count.
+func defaultMaxDeliveryCount(triggerType string) int {
+	switch triggerType {
+	case "queueTrigger":
+		return defaultQueueMaxDequeueCount
+	case "serviceBusTrigger":
+		return defaultServiceBusMaxDeliveryCount
+	}
+	return 0
+}
+
+// deliveryCount returns the delivery count of a message from the metadata
+// of the provided trigger type. Returns 0 for triggers without a delivery
+// count.
+func deliveryCount(triggerType string, metadata map[string]any) int {
+	var s string
+	switch triggerType {
+	case "queueTrigger":
+		s = metadataString(metadata, "DequeueCount")
+	case "serviceBusTrigger":
+		s = metadataString(metadata, "DeliveryCount")
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// metadataString returns the value of the first of the provided keys
+// in the metadata as a string, unquoted.
+func metadataString(metadata map[string]any, keys ...string) string {
+	for _, key := range keys {
+		switch v := metadata[key].(type) {
+		case string:
+			return strings.Trim(v, `"`)
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package azfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestWithPoisonOutput(t *testing.T) {
+	var tests = []struct {
+		name       string
+		trigger    FunctionOption
+		options    []PoisonOption
+		input      string
+		wantStatus int
+		want       *PoisonMessage
+	}{
+		{
+			name:       "success",
+			trigger:    testPoisonQueueTrigger(nil),
+			input:      `{"Data":{"queue":{"id":1}},"Metadata":{"DequeueCount":"5","Id":"1234"}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error below max dequeue count",
+			trigger:    testPoisonQueueTrigger(errTestFunction),
+			input:      `{"Data":{"queue":{"id":1}},"Metadata":{"DequeueCount":"4","Id":"1234"}}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "error at max dequeue count",
+			trigger:    testPoisonQueueTrigger(errTestFunction),
+			input:      `{"Data":{"queue":{"id":1}},"Metadata":{"DequeueCount":"5","Id":"1234"}}`,
+			wantStatus: http.StatusOK,
+			want: &PoisonMessage{
+				FunctionName:  "hello",
+				InvocationID:  "abcd",
+				TriggerType:   "queueTrigger",
+				MessageID:     "1234",
+				DeliveryCount: 5,
+				Error:         errTestFunction.Error(),
+				Data:          []byte(`{"id":1}`),
+			},
+		},
+		{
+			name:       "permanent error",
+			trigger:    testPoisonQueueTrigger(Permanent(errTestFunction)),
+			input:      `{"Data":{"queue":{"id":1}},"Metadata":{"DequeueCount":"1","Id":"1234"}}`,
+			wantStatus: http.StatusOK,
+			want: &PoisonMessage{
+				FunctionName:  "hello",
+				InvocationID:  "abcd",
+				TriggerType:   "queueTrigger",
+				MessageID:     "1234",
+				DeliveryCount: 1,
+				Error:         errTestFunction.Error(),
+				Permanent:     true,
+				Data:          []byte(`{"id":1}`),
+			},
+		},
+		{
+			name:    "error at custom max delivery count",
+			trigger: testPoisonQueueTrigger(Transient(errTestFunction)),
+			options: []PoisonOption{func(o *PoisonOptions) {
+				o.MaxDeliveryCount = 2
+			}},
+			input:      `{"Data":{"queue":{"id":1}},"Metadata":{"DequeueCount":"2","Id":"1234"}}`,
+			wantStatus: http.StatusOK,
+			want: &PoisonMessage{
+				FunctionName:  "hello",
+				InvocationID:  "abcd",
+				TriggerType:   "queueTrigger",
+				MessageID:     "1234",
+				DeliveryCount: 2,
+				Error:         errTestFunction.Error(),
+				Data:          []byte(`{"id":1}`),
+			},
+		},
+		{
+			name: "service bus error at max delivery count",
+			trigger: ServiceBusTrigger("message", func(ctx *Context, trigger *trigger.ServiceBus) error {
+				return errTestFunction
+			}),
+			input:      `{"Data":{"message":{"id":1}},"Metadata":{"DeliveryCount":"10","MessageId":"\"1234\""}}`,
+			wantStatus: http.StatusOK,
+			want: &PoisonMessage{
+				FunctionName:  "hello",
+				InvocationID:  "abcd",
+				TriggerType:   "serviceBusTrigger",
+				MessageID:     "1234",
+				DeliveryCount: 10,
+				Error:         errTestFunction.Error(),
+				Data:          []byte(`{"id":1}`),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello", test.trigger, WithPoisonOutput(output.NewQueue("poison"), test.options...))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello", bytes.NewReader([]byte(test.input)))
+			r.Header.Set(headerInvocationID, "abcd")
+			app.handler(app.functions["hello"]).ServeHTTP(w, r)
+
+			if diff := cmp.Diff(test.wantStatus, w.Code); diff != "" {
+				t.Errorf("WithPoisonOutput() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+			if test.want == nil {
+				return
+			}
+
+			var res struct {
+				Outputs map[string]string
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("WithPoisonOutput() = unexpected error: %v", err)
+			}
+			var got PoisonMessage
+			if err := json.Unmarshal([]byte(res.Outputs["poison"]), &got); err != nil {
+				t.Fatalf("WithPoisonOutput() = unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(*test.want, got, cmpopts.IgnoreFields(PoisonMessage{}, "Time")); diff != "" {
+				t.Errorf("WithPoisonOutput() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if got.Time.IsZero() {
+				t.Errorf("WithPoisonOutput() = expected time to be set\n")
+			}
+		})
+	}
+}
+
+func TestWithPoisonOutput_Bindings(t *testing.T) {
+	app := NewFunctionApp()
+	app.AddFunction("hello", testPoisonQueueTrigger(nil), WithPoisonOutput(output.NewQueue("poison", func(o *output.QueueOptions) {
+		o.QueueName = "hello-poison"
+	})))
+
+	got := app.functions["hello"].bindings()
+	want := binding{Name: "poison", Type: "queue", Direction: "out", QueueName: "hello-poison"}
+	if diff := cmp.Diff(want, got[len(got)-1]); diff != "" {
+		t.Errorf("bindings() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+func testPoisonQueueTrigger(err error) FunctionOption {
+	return QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		return err
+	})
+}
@@ -0,0 +1,52 @@
+package azfunc
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned when a function panics. It contains the value
+// passed to panic and the stack trace of the goroutine.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error returns the error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// PanicHook is a function that is called when a function panics, after
+// the panic has been recovered and logged. It can be used for alerting.
+type PanicHook func(ctx *Context, info FunctionInfo, err *PanicError)
+
+// WithPanicHook sets the provided hook to be called when a function
+// panics.
+func WithPanicHook(hook PanicHook) FunctionAppOption {
+	return func(a *functionApp) {
+		a.panicHook = hook
+	}
+}
+
+// recoverer returns a NextFunc that runs next and recovers from a panic.
+// The panic is logged with its stack trace to both the logger and the
+// invocation logger, and returned as a *PanicError.
+func (a functionApp) recoverer(info FunctionInfo, next NextFunc) NextFunc {
+	return func(ctx *Context) (err error) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			perr := &PanicError{Value: v, Stack: debug.Stack()}
+			ctx.Log().Error(perr.Error(), "stack", string(perr.Stack))
+			ctx.Outputs.Log().Error(perr.Error(), "stack", string(perr.Stack))
+			if a.panicHook != nil {
+				a.panicHook(ctx, info, perr)
+			}
+			err = perr
+		}()
+		return next(ctx)
+	}
+}
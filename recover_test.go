@@ -0,0 +1,49 @@
+package azfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KarlGW/azfunc/trigger"
+)
+
+func TestRecoverer(t *testing.T) {
+	var gotHook *PanicError
+	var gotInfo FunctionInfo
+	app := NewFunctionApp(WithDisableLogging(), WithPanicHook(func(ctx *Context, info FunctionInfo, err *PanicError) {
+		gotHook = err
+		gotInfo = info
+	}))
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		panic("something went wrong")
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+	app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("handler() = unexpected status code, want: %d, got: %d\n", http.StatusInternalServerError, w.Code)
+	}
+
+	var body struct {
+		Logs []string
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("handler() = unexpected error: %v", err)
+	}
+	if len(body.Logs) != 1 || !strings.Contains(body.Logs[0], "panic: something went wrong") || !strings.Contains(body.Logs[0], "stack") {
+		t.Errorf("handler() = unexpected logs: %v\n", body.Logs)
+	}
+
+	if gotHook == nil || gotHook.Value != "something went wrong" || len(gotHook.Stack) == 0 {
+		t.Errorf("WithPanicHook() = unexpected result: %v\n", gotHook)
+	}
+	if gotInfo.Name != "hello-queue" {
+		t.Errorf("WithPanicHook() = unexpected function name, want: hello-queue, got: %s\n", gotInfo.Name)
+	}
+}
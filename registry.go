@@ -0,0 +1,373 @@
+package azfunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrDependencyNotFound is returned when a dependency has not been provided
+// to the FunctionApp.
+var ErrDependencyNotFound = errors.New("dependency not found")
+
+// ErrCircularDependency is returned when a dependency is resolved while it
+// is being constructed, by its own factory or by the factory of one of its
+// dependencies.
+var ErrCircularDependency = errors.New("circular dependency")
+
+// ErrDependencyLifetime is returned when a per invocation dependency is
+// resolved by the factory of a singleton dependency, which would outlive it.
+var ErrDependencyLifetime = errors.New("per invocation dependency resolved by singleton")
+
+// Lifetime is the lifetime of a provided dependency.
+type Lifetime int
+
+const (
+	// Singleton dependencies are constructed once, on first use, and are
+	// shared by all invocations. They are closed when the FunctionApp is
+	// stopped.
+	Singleton Lifetime = iota
+	// PerInvocation dependencies are constructed once per invocation, on
+	// first use, and are closed when the invocation has finished.
+	PerInvocation
+)
+
+// Factory is a function that constructs a dependency. Other dependencies
+// can be resolved from the provided *Context. The factory of a singleton
+// is provided a *Context without invocation, that is not cancelled with
+// the invocation and that has no output bindings, and resolving a per
+// invocation dependency from it returns ErrDependencyLifetime.
+type Factory[T any] func(ctx *Context) (T, error)
+
+// ProvideOptions contains options for a provided dependency.
+type ProvideOptions struct {
+	// Name sets the name of the dependency, to provide multiple
+	// dependencies of the same type.
+	Name string
+	// Lifetime sets the lifetime of the dependency. Defaults to
+	// Singleton.
+	Lifetime Lifetime
+}
+
+// ProvideOption is a function that sets options for a provided dependency.
+type ProvideOption func(o *ProvideOptions)
+
+// Provide registers the provided factory as the constructor of the
+// dependency of type T. The dependency is constructed lazily on the
+// first call to Resolve, and if it has a Close method it is called when
+// the dependency reaches the end of its lifetime.
+func Provide[T any](factory Factory[T], options ...ProvideOption) FunctionAppOption {
+	return func(a *functionApp) {
+		if factory == nil {
+			return
+		}
+		opts := ProvideOptions{}
+		for _, option := range options {
+			option(&opts)
+		}
+		if a.registry == nil {
+			a.registry = newRegistry()
+		}
+		p := &provider{
+			lifetime: opts.Lifetime,
+			factory: func(ctx *Context) (any, error) {
+				return factory(ctx)
+			},
+		}
+		a.registry.add(keyOf[T](opts.Name), p)
+		if p.lifetime == Singleton {
+			a.stopHooks = append(a.stopHooks, func(ctx context.Context) error {
+				return p.close()
+			})
+		}
+	}
+}
+
+// ProvideValue registers the provided value as the dependency of type T.
+// If it has a Close method it is called when the FunctionApp is stopped.
+func ProvideValue[T any](v T, options ...ProvideOption) FunctionAppOption {
+	options = append(options, func(o *ProvideOptions) {
+		o.Lifetime = Singleton
+	})
+	return Provide(func(ctx *Context) (T, error) {
+		return v, nil
+	}, options...)
+}
+
+// Resolve returns the dependency of type T with the optional name,
+// constructing it if needed. ErrDependencyNotFound is returned if it has
+// not been provided.
+func Resolve[T any](ctx *Context, name ...string) (T, error) {
+	var t T
+	var n string
+	if len(name) > 0 {
+		n = name[0]
+	}
+	k := keyOf[T](n)
+
+	if ctx == nil || ctx.registry == nil {
+		return t, fmt.Errorf("%w: %s", ErrDependencyNotFound, k)
+	}
+	v, err := ctx.registry.resolve(ctx, k)
+	if err != nil {
+		return t, err
+	}
+	t, _ = v.(T)
+	return t, nil
+}
+
+// Require sets the function to require the dependency of type T with the
+// optional name. The FunctionApp fails to start if it has not been provided.
+func Require[T any](name ...string) FunctionOption {
+	var n string
+	if len(name) > 0 {
+		n = name[0]
+	}
+	return func(f *function) {
+		f.dependencies = append(f.dependencies, keyOf[T](n))
+	}
+}
+
+// dependencyKey identifies a dependency by type and name.
+type dependencyKey struct {
+	t    reflect.Type
+	name string
+}
+
+// String returns the string representation of the key.
+func (k dependencyKey) String() string {
+	if len(k.name) == 0 {
+		return k.t.String()
+	}
+	return k.t.String() + " (" + k.name + ")"
+}
+
+// keyOf returns the key of the dependency of type T with the provided name.
+func keyOf[T any](name string) dependencyKey {
+	return dependencyKey{t: reflect.TypeOf((*T)(nil)).Elem(), name: name}
+}
+
+// provider constructs and holds a dependency.
+type provider struct {
+	lifetime Lifetime
+	factory  func(ctx *Context) (any, error)
+	value    any
+	built    bool
+	mu       sync.Mutex
+}
+
+// get returns the singleton dependency of the provider, constructing it
+// on first use. A failed construction is retried on the next use.
+func (p *provider) get(ctx *Context, k dependencyKey) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.built {
+		return p.value, nil
+	}
+	v, err := p.factory(singletonContext(ctx, k))
+	if err != nil {
+		return nil, err
+	}
+	p.value, p.built = v, true
+	return v, nil
+}
+
+// close closes the singleton dependency of the provider, if it has been
+// constructed.
+func (p *provider) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.built {
+		return nil
+	}
+	p.built = false
+	return closeDependency(p.value)
+}
+
+// registry contains the providers of the dependencies of a FunctionApp.
+type registry struct {
+	providers map[dependencyKey]*provider
+	// log is the logger of singleton dependencies, without the attributes
+	// of an invocation.
+	log Logger
+}
+
+// newRegistry creates and returns a new registry.
+func newRegistry() *registry {
+	return &registry{
+		providers: make(map[dependencyKey]*provider),
+	}
+}
+
+// add the provider of the dependency with the provided key.
+func (r *registry) add(k dependencyKey, p *provider) {
+	r.providers[k] = p
+}
+
+// has returns true if the dependency with the provided key has been
+// provided.
+func (r *registry) has(k dependencyKey) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.providers[k]
+	return ok
+}
+
+// resolve returns the dependency with the provided key. Per invocation
+// dependencies are held by the scope of the *Context.
+func (r *registry) resolve(ctx *Context, k dependencyKey) (any, error) {
+	p, ok := r.providers[k]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyNotFound, k)
+	}
+	for i, key := range ctx.resolving {
+		if key == k {
+			return nil, fmt.Errorf("%w: %s", ErrCircularDependency, dependencyChain(append(ctx.resolving[i:len(ctx.resolving):len(ctx.resolving)], k)))
+		}
+	}
+	if p.lifetime == Singleton {
+		return p.get(ctx, k)
+	}
+	if ctx.scope == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyLifetime, k)
+	}
+	return ctx.scope.get(ctx, k, p)
+}
+
+// resolvingContext returns a copy of the provided *Context, with the
+// provided key added to the keys of the dependencies being constructed.
+func resolvingContext(ctx *Context, k dependencyKey) *Context {
+	c := *ctx
+	c.resolving = append(ctx.resolving[:len(ctx.resolving):len(ctx.resolving)], k)
+	return &c
+}
+
+// dependencyChain returns the string representation of the provided
+// keys, in the order they were resolved.
+func dependencyChain(keys []dependencyKey) string {
+	chain := make([]string, len(keys))
+	for i, k := range keys {
+		chain[i] = k.String()
+	}
+	return strings.Join(chain, " -> ")
+}
+
+// singletonContext returns the *Context the singleton dependency with the
+// provided key is constructed with, from the *Context that resolves it. It keeps the values of the context, but not its cancellation,
+// the invocation, the scope or the output bindings, so that the dependency does not capture
+// the state of the invocation.
+func singletonContext(ctx *Context, k dependencyKey) *Context {
+	var c context.Context = context.Background()
+	if ctx.Context != nil {
+		c = context.WithoutCancel(ctx.Context)
+	}
+	log := ctx.log
+	if ctx.registry != nil && ctx.registry.log != nil {
+		log = ctx.registry.log
+	}
+	return &Context{
+		Context:   c,
+		log:       log,
+		services:  ctx.services,
+		clients:   ctx.clients,
+		tracer:    ctx.tracer,
+		registry:  ctx.registry,
+		resolving: append(ctx.resolving[:len(ctx.resolving):len(ctx.resolving)], k),
+	}
+}
+
+// scope holds the per invocation dependencies of an invocation.
+type scope struct {
+	values map[dependencyKey]any
+	keys   []dependencyKey
+	mu     sync.Mutex
+}
+
+// get returns the per invocation dependency with the provided key,
+// constructing it with the provider on first use. The lock is not held
+// while constructing, so that factories can resolve other per invocation
+// dependencies.
+func (s *scope) get(ctx *Context, k dependencyKey, p *provider) (any, error) {
+	s.mu.Lock()
+	v, ok := s.values[k]
+	s.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := p.factory(resolvingContext(ctx, k))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.values[k]; ok {
+		// Constructed concurrently, keep the first one.
+		return existing, closeDependency(v)
+	}
+	if s.values == nil {
+		s.values = make(map[dependencyKey]any)
+	}
+	s.values[k] = v
+	s.keys = append(s.keys, k)
+	return v, nil
+}
+
+// close closes the dependencies of the scope in the reverse order of
+// their construction.
+func (s *scope) close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if err := closeDependency(s.values[s.keys[i]]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.values, s.keys = nil, nil
+	return errors.Join(errs...)
+}
+
+// closeDependency calls the Close method of the provided dependency,
+// if it has one.
+func closeDependency(v any) error {
+	switch c := v.(type) {
+	case interface{ Close() error }:
+		return c.Close()
+	case interface{ Close() }:
+		c.Close()
+	}
+	return nil
+}
+
+// checkDependencies checks that the dependencies required by the functions
+// of the FunctionApp have been provided.
+func (a functionApp) checkDependencies() error {
+	names := make([]string, 0, len(a.functions))
+	for name := range a.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		for _, k := range a.functions[name].dependencies {
+			if !a.registry.has(k) {
+				errs = append(errs, fmt.Errorf("function %s: %w: %s", name, ErrDependencyNotFound, k))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
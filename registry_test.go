@@ -0,0 +1,335 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolve(t *testing.T) {
+	var tests = []struct {
+		name    string
+		options []FunctionAppOption
+		input   []string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "resolve by type",
+			options: []FunctionAppOption{
+				ProvideValue(&testDependency{name: "db"}),
+			},
+			want: "db",
+		},
+		{
+			name: "resolve by type and name",
+			options: []FunctionAppOption{
+				ProvideValue(&testDependency{name: "db"}),
+				ProvideValue(&testDependency{name: "cache"}, func(o *ProvideOptions) {
+					o.Name = "cache"
+				}),
+			},
+			input: []string{"cache"},
+			want:  "cache",
+		},
+		{
+			name: "resolve with factory",
+			options: []FunctionAppOption{
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return &testDependency{name: "db"}, nil
+				}),
+			},
+			want: "db",
+		},
+		{
+			name: "factory error",
+			options: []FunctionAppOption{
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return nil, errTestDependency
+				}),
+			},
+			wantErr: errTestDependency,
+		},
+		{
+			name:    "not provided",
+			input:   []string{"cache"},
+			wantErr: ErrDependencyNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(test.options...)
+			ctx := newContext(context.Background(), func(o *contextOptions) {
+				o.registry = app.registry
+			})
+
+			got, gotErr := Resolve[*testDependency](ctx, test.input...)
+
+			var gotName string
+			if got != nil {
+				gotName = got.name
+			}
+			if diff := cmp.Diff(test.want, gotName); diff != "" {
+				t.Errorf("Resolve() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Resolve() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestProvide_Lifetime(t *testing.T) {
+	var singletons, perInvocation []*testDependency
+	app := NewFunctionApp(
+		WithDisableLogging(),
+		Provide(func(ctx *Context) (*testDependency, error) {
+			d := &testDependency{name: "singleton"}
+			singletons = append(singletons, d)
+			return d, nil
+		}),
+		Provide(func(ctx *Context) (*testDependency, error) {
+			d := &testDependency{name: "per-invocation"}
+			perInvocation = append(perInvocation, d)
+			return d, nil
+		}, func(o *ProvideOptions) {
+			o.Name = "scoped"
+			o.Lifetime = PerInvocation
+		}),
+	)
+	app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+		for i := 0; i < 2; i++ {
+			if _, err := Resolve[*testDependency](ctx); err != nil {
+				return err
+			}
+			if _, err := Resolve[*testDependency](ctx, "scoped"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), Require[*testDependency](), Require[*testDependency]("scoped"))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+		app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+	}
+
+	if len(singletons) != 1 {
+		t.Errorf("Provide() = unexpected number of singletons, want: 1, got: %d\n", len(singletons))
+	}
+	if len(perInvocation) != 2 {
+		t.Fatalf("Provide() = unexpected number of per invocation dependencies, want: 2, got: %d\n", len(perInvocation))
+	}
+	for _, d := range perInvocation {
+		if !d.closed {
+			t.Errorf("Provide() = per invocation dependency not closed\n")
+		}
+	}
+	if singletons[0].closed {
+		t.Errorf("Provide() = singleton closed before shutdown\n")
+	}
+
+	if err := app.shutdown(); err != nil {
+		t.Errorf("Provide() = unexpected error on shutdown: %v\n", err)
+	}
+	if !singletons[0].closed {
+		t.Errorf("Provide() = singleton not closed on shutdown\n")
+	}
+}
+
+func TestProvide_SingletonContext(t *testing.T) {
+	var tests = []struct {
+		name    string
+		resolve bool
+		wantErr error
+	}{
+		{
+			name: "singleton",
+		},
+		{
+			name:    "singleton resolves per invocation dependency",
+			resolve: true,
+			wantErr: ErrDependencyLifetime,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var factoryCtx *Context
+			app := NewFunctionApp(
+				WithDisableLogging(),
+				Provide(func(ctx *Context) (*testDependency, error) {
+					factoryCtx = ctx
+					if test.resolve {
+						if _, err := Resolve[*testDependency](ctx, "scoped"); err != nil {
+							return nil, err
+						}
+					}
+					return &testDependency{name: "singleton"}, nil
+				}),
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return &testDependency{name: "per-invocation"}, nil
+				}, func(o *ProvideOptions) {
+					o.Name = "scoped"
+					o.Lifetime = PerInvocation
+				}),
+			)
+
+			var gotErr error
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				_, gotErr = Resolve[*testDependency](ctx)
+				return nil
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+			r.Header.Set(headerInvocationID, "1234")
+			app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Resolve() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if factoryCtx == nil {
+				t.Fatalf("Provide() = factory not called\n")
+			}
+			if factoryCtx.InvocationID() != "" {
+				t.Errorf("Provide() = unexpected invocation ID of singleton context, want: empty, got: %s\n", factoryCtx.InvocationID())
+			}
+			if factoryCtx.scope != nil {
+				t.Errorf("Provide() = unexpected scope of singleton context\n")
+			}
+			if factoryCtx.Err() != nil {
+				t.Errorf("Provide() = singleton context cancelled with the invocation: %v\n", factoryCtx.Err())
+			}
+		})
+	}
+}
+
+func TestResolve_CircularDependency(t *testing.T) {
+	var tests = []struct {
+		name    string
+		options []FunctionAppOption
+		want    string
+	}{
+		{
+			name: "singleton resolves itself",
+			options: []FunctionAppOption{
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return Resolve[*testDependency](ctx)
+				}),
+			},
+			want: "*azfunc.testDependency -> *azfunc.testDependency",
+		},
+		{
+			name: "singletons resolve each other",
+			options: []FunctionAppOption{
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return Resolve[*testDependency](ctx, "b")
+				}),
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return Resolve[*testDependency](ctx)
+				}, func(o *ProvideOptions) {
+					o.Name = "b"
+				}),
+			},
+			want: "*azfunc.testDependency -> *azfunc.testDependency (b) -> *azfunc.testDependency",
+		},
+		{
+			name: "per invocation dependency resolves itself",
+			options: []FunctionAppOption{
+				Provide(func(ctx *Context) (*testDependency, error) {
+					return Resolve[*testDependency](ctx)
+				}, func(o *ProvideOptions) {
+					o.Lifetime = PerInvocation
+				}),
+			},
+			want: "*azfunc.testDependency -> *azfunc.testDependency",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(test.options...)
+			ctx := newContext(context.Background(), func(o *contextOptions) {
+				o.registry = app.registry
+			})
+
+			errCh := make(chan error, 1)
+			go func() {
+				_, err := Resolve[*testDependency](ctx)
+				errCh <- err
+			}()
+
+			var gotErr error
+			select {
+			case gotErr = <-errCh:
+			case <-time.After(time.Second):
+				t.Fatalf("Resolve() = deadlock\n")
+			}
+
+			if !errors.Is(gotErr, ErrCircularDependency) {
+				t.Errorf("Resolve() = unexpected error, want: %v, got: %v\n", ErrCircularDependency, gotErr)
+			}
+			if gotErr != nil && !strings.HasSuffix(gotErr.Error(), test.want) {
+				t.Errorf("Resolve() = unexpected error, want suffix: %s, got: %v\n", test.want, gotErr)
+			}
+		})
+	}
+}
+
+func TestFunctionApp_CheckDependencies(t *testing.T) {
+	var tests = []struct {
+		name    string
+		options []FunctionAppOption
+		wantErr error
+	}{
+		{
+			name: "provided",
+			options: []FunctionAppOption{
+				ProvideValue(&testDependency{}),
+			},
+		},
+		{
+			name:    "missing",
+			wantErr: ErrDependencyNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := NewFunctionApp(test.options...)
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				return nil
+			}), Require[*testDependency]())
+
+			gotErr := app.checkDependencies()
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("checkDependencies() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+var errTestDependency = errors.New("test dependency error")
+
+type testDependency struct {
+	name   string
+	closed bool
+}
+
+func (d *testDependency) Close() error {
+	d.closed = true
+	return nil
+}
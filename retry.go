@@ -0,0 +1,224 @@
+package azfunc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/KarlGW/azfunc/output"
+)
+
+const (
+	// defaultRetryMaxAttempts is the default maximum number of attempts
+	// of a function with a retry policy.
+	defaultRetryMaxAttempts = 3
+	// defaultRetryInitialDelay is the default initial delay of the
+	// exponential backoff of a retry policy.
+	defaultRetryInitialDelay = 1 * time.Second
+	// defaultRetryMaxDelay is the default maximum delay of the
+	// exponential backoff of a retry policy.
+	defaultRetryMaxDelay = 30 * time.Second
+)
+
+// Backoff is a function that returns the delay before the next attempt,
+// after the provided attempt (starting at 1) has failed.
+type Backoff func(attempt int) time.Duration
+
+// FixedBackoff returns a Backoff with the same delay between every attempt.
+func FixedBackoff(d time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a Backoff where the delay starts at initial
+// and is doubled after every attempt, up to max.
+func ExponentialBackoff(initial, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := initial
+		for i := 1; i < attempt; i++ {
+			d *= 2
+			if d >= max || d <= 0 {
+				return max
+			}
+		}
+		if d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// RetryOptions contains options for the retry policy of a function.
+type RetryOptions struct {
+	// MaxAttempts sets the maximum number of attempts, including the
+	// first. Defaults to 3.
+	MaxAttempts int
+	// Backoff sets the delay between attempts. Defaults to an exponential
+	// backoff that starts at 1 second, up to 30 seconds.
+	Backoff Backoff
+	// Jitter sets the fraction (0-1) of the delay that is randomized, to
+	// spread out attempts. A jitter of 0.2 reduces the delay by a random
+	// amount of up to 20%. Defaults to 0.
+	Jitter float64
+	// Retryable sets the function that reports if an attempt that failed
+	// with the provided error should be retried. Defaults to retrying all
+	// errors except panics and permanent errors (BadRequest and Permanent).
+	Retryable func(err error) bool
+}
+
+// RetryOption is a function that sets options for the retry policy of
+// a function.
+type RetryOption func(o *RetryOptions)
+
+// retryPolicy contains the retry policy of a function.
+type retryPolicy struct {
+	maxAttempts int
+	backoff     Backoff
+	jitter      float64
+	retryable   func(err error) bool
+}
+
+// WithRetry sets the function to be retried in-process when it returns
+// an error. Every attempt is run with fresh outputs, so that the output
+// bindings written by a failed attempt are discarded. The invocation logs
+// are kept, with the attempt added to the entries.
+func WithRetry(options ...RetryOption) FunctionOption {
+	return func(f *function) {
+		opts := RetryOptions{
+			MaxAttempts: defaultRetryMaxAttempts,
+			Backoff:     ExponentialBackoff(defaultRetryInitialDelay, defaultRetryMaxDelay),
+			Retryable:   isRetryable,
+		}
+		for _, option := range options {
+			option(&opts)
+		}
+		if opts.MaxAttempts < 1 {
+			opts.MaxAttempts = 1
+		}
+		if opts.Backoff == nil {
+			opts.Backoff = FixedBackoff(0)
+		}
+		if opts.Retryable == nil {
+			opts.Retryable = isRetryable
+		}
+		if opts.Jitter < 0 {
+			opts.Jitter = 0
+		} else if opts.Jitter > 1 {
+			opts.Jitter = 1
+		}
+
+		f.retry = &retryPolicy{
+			maxAttempts: opts.MaxAttempts,
+			backoff:     opts.Backoff,
+			jitter:      opts.Jitter,
+			retryable:   opts.Retryable,
+		}
+	}
+}
+
+// isRetryable is the default retryable predicate. It retries all errors
+// except panics and permanent errors.
+func isRetryable(err error) bool {
+	var perr *PanicError
+	return !errors.As(err, &perr) && !IsPermanent(err)
+}
+
+// delay returns the delay after the provided attempt, with jitter.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := p.backoff(attempt)
+	if p.jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.jitter * float64(d))
+	}
+	return d
+}
+
+// retrier returns a NextFunc that runs next according to the retry policy
+// of the function. The body of the request is buffered so that it can be
+// read by the trigger of every attempt, and every attempt after the first is
+// run with fresh outputs. Panics are recovered per attempt, so that they are
+// passed to the retryable predicate. Retrying stops if the Context is done.
+func (a functionApp) retrier(fn function, r *http.Request, next NextFunc) NextFunc {
+	p := fn.retry
+	if p == nil {
+		return next
+	}
+
+	return func(ctx *Context) error {
+		body, err := readBody(r)
+		if err != nil {
+			return err
+		}
+		log := ctx.Outputs.log
+
+		for attempt := 1; ; attempt++ {
+			if attempt > 1 {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				ctx.Outputs = newOutputs(withOutputs(cloneOutputs(fn.outputs...)...))
+			}
+			ctx.Outputs.log = log
+			if l, ok := log.(invocationLogger); ok {
+				ctx.Outputs.log = l.with("attempt", attempt)
+			}
+
+			err := a.recoverer(fn.info(), next)(ctx)
+			if err == nil || attempt >= p.maxAttempts || !p.retryable(err) {
+				return err
+			}
+
+			d := p.delay(attempt)
+			ctx.Log().Warn("Attempt failed, retrying.", "attempt", attempt, "maxAttempts", p.maxAttempts, "delay", d.String(), "error", err.Error())
+			ctx.Outputs.Log().Warn("Attempt failed, retrying.", "maxAttempts", p.maxAttempts, "delay", d.String(), "error", err.Error())
+
+			timer := time.NewTimer(d)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+		}
+	}
+}
+
+// cloneOutputs returns copies of the provided output bindings, so that
+// writes to them are not shared between invocations or attempts.
+func cloneOutputs(outputs ...outputable) []outputable {
+	clones := make([]outputable, 0, len(outputs))
+	for _, o := range outputs {
+		clones = append(clones, cloneOutput(o))
+	}
+	return clones
+}
+
+// cloneOutput returns a copy of the provided output binding.
+func cloneOutput(o outputable) outputable {
+	switch b := o.(type) {
+	case *output.HTTP:
+		return output.NewHTTP(func(o *output.HTTPOptions) {
+			o.Name = b.Name()
+			o.StatusCode = b.StatusCode()
+			o.Body = b.Data()
+			o.Header = b.Header().Clone()
+		})
+	case *output.Queue:
+		c := *b
+		return &c
+	case *output.ServiceBus:
+		c := *b
+		return &c
+	case *output.EventGrid:
+		c := *b
+		return &c
+	case *output.Kafka:
+		c := *b
+		return &c
+	case *output.Generic:
+		c := *b
+		return &c
+	}
+	return o
+}
@@ -0,0 +1,238 @@
+package azfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/output"
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input int
+		want  time.Duration
+	}{
+		{
+			name:  "first attempt",
+			input: 1,
+			want:  100 * time.Millisecond,
+		},
+		{
+			name:  "third attempt",
+			input: 3,
+			want:  400 * time.Millisecond,
+		},
+		{
+			name:  "max",
+			input: 10,
+			want:  time.Second,
+		},
+		{
+			name:  "overflow",
+			input: 100,
+			want:  time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ExponentialBackoff(100*time.Millisecond, time.Second)(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ExponentialBackoff() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := retryPolicy{backoff: FixedBackoff(time.Second), jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if got := p.delay(1); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("delay() = unexpected result, want: between 500ms and 1s, got: %s\n", got)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	var tests = []struct {
+		name         string
+		input        []RetryOption
+		failUntil    int
+		panics       bool
+		wantAttempts int
+		wantStatus   int
+		wantOutput   string
+	}{
+		{
+			name:         "succeeds after retry",
+			failUntil:    2,
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+			wantOutput:   "attempt 2",
+		},
+		{
+			name:         "max attempts",
+			input:        []RetryOption{func(o *RetryOptions) { o.MaxAttempts = 2 }},
+			failUntil:    5,
+			wantAttempts: 2,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name: "not retryable",
+			input: []RetryOption{func(o *RetryOptions) {
+				o.Retryable = func(err error) bool {
+					return !errors.Is(err, errTestRetry)
+				}
+			}},
+			failUntil:    5,
+			wantAttempts: 1,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:         "panics are not retried",
+			failUntil:    5,
+			panics:       true,
+			wantAttempts: 1,
+			wantStatus:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int
+			var data []string
+			app := NewFunctionApp(WithDisableLogging())
+			app.AddFunction("hello-queue", QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+				attempts++
+				data = append(data, string(trigger.Data))
+				ctx.Outputs.Binding("out").Write([]byte("attempt " + strconv.Itoa(attempts)))
+				if attempts == 1 {
+					ctx.Outputs.Binding("partial").Write([]byte("partial"))
+				}
+				if attempts < test.failUntil {
+					if test.panics {
+						panic("panic")
+					}
+					return errTestRetry
+				}
+				return nil
+			}),
+				WithOutput(output.NewQueue("out")),
+				WithRetry(append([]RetryOption{func(o *RetryOptions) {
+					o.Backoff = FixedBackoff(0)
+				}}, test.input...)...),
+			)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello-queue", bytes.NewReader([]byte(`{"Data":{"queue":"hello"},"Metadata":{}}`)))
+			app.handler(app.functions["hello-queue"]).ServeHTTP(w, r)
+
+			if diff := cmp.Diff(test.wantAttempts, attempts); diff != "" {
+				t.Errorf("WithRetry() = unexpected attempts (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantStatus, w.Code); diff != "" {
+				t.Errorf("WithRetry() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+			for _, d := range data {
+				if d != "hello" {
+					t.Errorf("WithRetry() = unexpected trigger data, want: hello, got: %s\n", d)
+				}
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Outputs map[string]string
+				Logs    []string
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("WithRetry() = unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(map[string]string{"out": test.wantOutput}, res.Outputs); diff != "" {
+				t.Errorf("WithRetry() = unexpected outputs (-want +got)\n%s\n", diff)
+			}
+
+			var retries int
+			for _, entry := range res.Logs {
+				if strings.Contains(entry, "Attempt failed, retrying.") && strings.Contains(entry, `"attempt":`+strconv.Itoa(retries+1)) {
+					retries++
+				}
+			}
+			if diff := cmp.Diff(test.wantAttempts-1, retries); diff != "" {
+				t.Errorf("WithRetry() = unexpected retries in logs (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input error
+		want  bool
+	}{
+		{
+			name:  "error",
+			input: errTestRetry,
+			want:  true,
+		},
+		{
+			name:  "transient",
+			input: Transient(errTestRetry),
+			want:  true,
+		},
+		{
+			name:  "permanent",
+			input: Permanent(errTestRetry),
+		},
+		{
+			name:  "panic",
+			input: &PanicError{Value: "panic"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := isRetryable(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("isRetryable() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCloneOutputs(t *testing.T) {
+	queue := output.NewQueue("queue", func(o *output.QueueOptions) {
+		o.QueueName = "queue"
+	})
+	res := output.NewHTTP()
+	got := cloneOutputs(queue, res)
+
+	got[0].Write([]byte("hello"))
+	got[1].(*output.HTTP).Header().Set("Content-Type", "text/plain")
+
+	if len(queue.Data()) != 0 {
+		t.Errorf("cloneOutputs() = original output binding written to: %s\n", queue.Data())
+	}
+	if len(res.Header()) != 0 {
+		t.Errorf("cloneOutputs() = original header written to: %v\n", res.Header())
+	}
+	if diff := cmp.Diff(queue.Binding(), got[0].(*output.Queue).Binding()); diff != "" {
+		t.Errorf("cloneOutputs() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+var errTestRetry = errors.New("retry error")
@@ -26,8 +26,28 @@ echo ""
 
 echo "Testing..."
 go test ./...
+if [[ ! -f go.work ]]; then
+  go work init . ./otel
+fi
+(cd otel && go vet ./... && go test ./...) || exit 1
 echo ""
 
 echo "Creating and pushing tag..."
 git tag -a $tag -m "Version $version"
 git push origin $tag
+echo ""
+
+otel_tag=otel/$tag
+echo "Creating tag $otel_tag for version $version of the otel module."
+echo ""
+
+echo "Updating the otel module to require $tag..."
+(cd otel && go mod edit -require=github.com/KarlGW/azfunc@$tag && GOWORK=off GOPROXY=direct GONOSUMDB=github.com/KarlGW/azfunc go mod tidy) || exit 1
+git add otel/go.mod otel/go.sum
+git commit -m "Require azfunc $tag in otel module"
+git push
+echo ""
+
+echo "Creating and pushing tag..."
+git tag -a $otel_tag -m "Version $version of the otel module"
+git push origin $otel_tag
@@ -0,0 +1,231 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// headerTraceParent is the key of the W3C traceparent.
+	headerTraceParent = "traceparent"
+	// headerTraceState is the key of the W3C tracestate.
+	headerTraceState = "tracestate"
+	// headerDiagnosticID is the key of the application property the Azure SDKs
+	// set with the trace context of Service Bus messages.
+	headerDiagnosticID = "Diagnostic-Id"
+)
+
+// ErrTraceMessageNotObject is returned when trace context is injected into
+// a message that is not a JSON object or an array of JSON objects.
+var ErrTraceMessageNotObject = errors.New("trace context can only be injected into JSON objects")
+
+// Tracer is the interface that wraps around the methods Start and Inject.
+// It starts the span of every invocation and injects the trace context of
+// the invocation. The package github.com/KarlGW/azfunc/otel contains an
+// OpenTelemetry implementation.
+type Tracer interface {
+	// Start starts the span of the invocation, with the trace context of
+	// the provided carrier as parent. The keys of the carrier are in lower
+	// case (e.g. traceparent and tracestate).
+	Start(ctx context.Context, carrier map[string]string, info SpanInfo) (context.Context, Span)
+	// Inject returns the trace context of the provided context as
+	// key-value pairs.
+	Inject(ctx context.Context) map[string]string
+}
+
+// Span is the interface that wraps around the method End. It is the span
+// of an invocation started by a Tracer.
+type Span interface {
+	// End ends the span, with the error returned by the invocation
+	// (if any).
+	End(err error)
+}
+
+// SpanInfo contains information about the invocation of a span.
+type SpanInfo struct {
+	FunctionInfo
+	// InvocationID is the ID of the invocation, as provided by the
+	// function host.
+	InvocationID string
+	// StartTime is the time (UTC) the invocation started.
+	StartTime time.Time
+}
+
+// WithTracer sets the Tracer of the FunctionApp, which enables tracing. A
+// span is started for every invocation, with the trace context of the HTTP
+// trigger headers, the Service Bus application properties (traceparent,
+// tracestate and Diagnostic-Id) or the message (traceparent and tracestate)
+// as parent.
+func WithTracer(tracer Tracer) FunctionAppOption {
+	return func(a *functionApp) {
+		a.tracer = tracer
+	}
+}
+
+// TraceContext returns the trace context of the invocation as key-value
+// pairs (e.g. traceparent and tracestate). It is empty if tracing has not
+// been enabled with WithTracer.
+func (c Context) TraceContext() map[string]string {
+	if c.Context == nil || c.tracer == nil {
+		return map[string]string{}
+	}
+	return c.tracer.Inject(c.Context)
+}
+
+// InjectTraceContext encodes v to JSON and adds the trace context of the
+// invocation to it, to be written to an output binding such as
+// output.ServiceBus, output.Queue and output.EventGrid. The trace context
+// is added as top-level properties (traceparent and tracestate), which
+// for a CloudEvent are the attributes of its distributed tracing extension.
+// If v is an array, the trace context is added to each of its elements.
+func InjectTraceContext(ctx *Context, v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	tc := ctx.TraceContext()
+	if len(tc) == 0 {
+		return b, nil
+	}
+
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		var messages []map[string]any
+		if err := json.Unmarshal(b, &messages); err != nil {
+			return nil, ErrTraceMessageNotObject
+		}
+		for _, message := range messages {
+			for k, v := range tc {
+				message[k] = v
+			}
+		}
+		return json.Marshal(messages)
+	}
+
+	var message map[string]any
+	if err := json.Unmarshal(b, &message); err != nil || message == nil {
+		return nil, ErrTraceMessageNotObject
+	}
+	for k, v := range tc {
+		message[k] = v
+	}
+	return json.Marshal(message)
+}
+
+// traceCarrier returns a carrier with the trace context of the request
+// based on the trigger of the function. The body of the request is
+// restored to be read by the trigger.
+func traceCarrier(r *http.Request, info FunctionInfo) (map[string]string, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	b, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Data     map[string]json.RawMessage
+		Metadata map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return nil, nil
+	}
+	d := payload.Data[info.TriggerName]
+
+	switch info.TriggerType {
+	case "httpTrigger":
+		var req struct {
+			Headers map[string][]string
+		}
+		if err := json.Unmarshal(d, &req); err != nil {
+			return nil, nil
+		}
+		return headerCarrier(req.Headers), nil
+	case "serviceBusTrigger":
+		carrier := messageCarrier(d)
+		var diagnosticID string
+		for _, name := range []string{"UserProperties", "ApplicationProperties"} {
+			var properties map[string]any
+			if err := json.Unmarshal(payload.Metadata[name], &properties); err != nil {
+				continue
+			}
+			for k, v := range properties {
+				s, ok := v.(string)
+				if !ok {
+					continue
+				}
+				switch {
+				case strings.EqualFold(k, headerTraceParent):
+					carrier[headerTraceParent] = s
+				case strings.EqualFold(k, headerTraceState):
+					carrier[headerTraceState] = s
+				case strings.EqualFold(k, headerDiagnosticID):
+					diagnosticID = s
+				}
+			}
+		}
+		if _, ok := carrier[headerTraceParent]; !ok && len(diagnosticID) > 0 {
+			carrier[headerTraceParent] = diagnosticID
+		}
+		return carrier, nil
+	}
+	return messageCarrier(d), nil
+}
+
+// messageCarrier returns a carrier with the trace context (traceparent and
+// tracestate) of the provided message, if it is a JSON object. The message
+// can be an encoded JSON string, as sent by the function host for some
+// triggers.
+func messageCarrier(d json.RawMessage) map[string]string {
+	carrier := map[string]string{}
+	if len(d) > 0 && d[0] == '"' {
+		var s string
+		if err := json.Unmarshal(d, &s); err != nil {
+			return carrier
+		}
+		d = json.RawMessage(s)
+	}
+
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal(d, &message); err != nil {
+		return carrier
+	}
+	for _, k := range []string{headerTraceParent, headerTraceState} {
+		var s string
+		if err := json.Unmarshal(message[k], &s); err == nil && len(s) > 0 {
+			carrier[k] = s
+		}
+	}
+	return carrier
+}
+
+// headerCarrier returns a carrier with the provided headers, with the keys
+// in lower case and the first value of every header.
+func headerCarrier(header map[string][]string) map[string]string {
+	carrier := make(map[string]string, len(header))
+	for k, values := range header {
+		if len(values) > 0 {
+			carrier[strings.ToLower(k)] = values[0]
+		}
+	}
+	return carrier
+}
+
+// readBody reads the body of the request and restores it, so that it
+// can be read again.
+func readBody(r *http.Request) ([]byte, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
@@ -0,0 +1,220 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithTracer(t *testing.T) {
+	var tests = []struct {
+		name    string
+		options []FunctionOption
+		body    []byte
+		want    testSpan
+	}{
+		{
+			name: "HTTP trigger with traceparent header",
+			options: []FunctionOption{
+				HTTPTrigger(func(ctx *Context, trigger *trigger.HTTP) error {
+					return nil
+				}),
+			},
+			body: []byte(`{"Data":{"req":{"Headers":{"Traceparent":["00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"]}}},"Metadata":{}}`),
+			want: testSpan{
+				carrier: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+				info:    FunctionInfo{Name: "hello", TriggerType: "httpTrigger", TriggerName: "req"},
+			},
+		},
+		{
+			name: "Service Bus trigger with Diagnostic-Id application property",
+			options: []FunctionOption{
+				ServiceBusTrigger("message", func(ctx *Context, trigger *trigger.ServiceBus) error {
+					return nil
+				}),
+			},
+			body: []byte(`{"Data":{"message":"hello"},"Metadata":{"ApplicationProperties":{"Diagnostic-Id":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}}`),
+			want: testSpan{
+				carrier: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+				info:    FunctionInfo{Name: "hello", TriggerType: "serviceBusTrigger", TriggerName: "message"},
+			},
+		},
+		{
+			name: "Queue trigger with traceparent in message",
+			options: []FunctionOption{
+				QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+					return errors.New("error")
+				}),
+			},
+			body: []byte(`{"Data":{"queue":"{\"traceparent\":\"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01\"}"},"Metadata":{}}`),
+			want: testSpan{
+				carrier: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+				info:    FunctionInfo{Name: "hello", TriggerType: "queueTrigger", TriggerName: "queue"},
+				err:     "error",
+			},
+		},
+		{
+			name: "Queue trigger without trace context",
+			options: []FunctionOption{
+				QueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue) error {
+					return nil
+				}),
+			},
+			body: []byte(`{"Data":{"queue":"hello"},"Metadata":{}}`),
+			want: testSpan{
+				carrier: map[string]string{},
+				info:    FunctionInfo{Name: "hello", TriggerType: "queueTrigger", TriggerName: "queue"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracer := &testTracer{}
+
+			var traceContext map[string]string
+			options := append(test.options, WithFunctionMiddleware(func(ctx *Context, info FunctionInfo, next NextFunc) error {
+				traceContext = ctx.TraceContext()
+				return next(ctx)
+			}))
+
+			app := NewFunctionApp(WithDisableLogging(), WithTracer(tracer))
+			app.AddFunction("hello", options...)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/hello", bytes.NewReader(test.body))
+			app.handler(app.functions["hello"]).ServeHTTP(w, r)
+
+			if len(tracer.spans) != 1 {
+				t.Fatalf("WithTracer() = unexpected number of spans, want: 1, got: %d\n", len(tracer.spans))
+			}
+			got := *tracer.spans[0]
+
+			if diff := cmp.Diff(test.want.carrier, got.carrier); diff != "" {
+				t.Errorf("WithTracer() = unexpected carrier (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want.info, got.info); diff != "" {
+				t.Errorf("WithTracer() = unexpected function info (-want +got)\n%s\n", diff)
+			}
+			if test.want.err != got.err {
+				t.Errorf("WithTracer() = unexpected error, want: %s, got: %s\n", test.want.err, got.err)
+			}
+			if !got.ended {
+				t.Errorf("WithTracer() = span not ended\n")
+			}
+			if diff := cmp.Diff(map[string]string{"span": "hello"}, traceContext); diff != "" {
+				t.Errorf("TraceContext() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestInjectTraceContext(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := newContext(context.Background(), func(o *contextOptions) {
+		o.tracer = testTraceContextTracer{"traceparent": traceparent}
+	})
+
+	var tests = []struct {
+		name    string
+		input   any
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:  "object",
+			input: map[string]any{"message": "hello"},
+			want:  []byte(`{"message":"hello","traceparent":"` + traceparent + `"}`),
+		},
+		{
+			name:  "array",
+			input: []map[string]any{{"message": "hello"}, {"message": "world"}},
+			want:  []byte(`[{"message":"hello","traceparent":"` + traceparent + `"},{"message":"world","traceparent":"` + traceparent + `"}]`),
+		},
+		{
+			name:    "not an object",
+			input:   "hello",
+			wantErr: ErrTraceMessageNotObject,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := InjectTraceContext(ctx, test.input)
+
+			if diff := cmp.Diff(string(test.want), string(got)); diff != "" {
+				t.Errorf("InjectTraceContext() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("InjectTraceContext() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestInjectTraceContext_Disabled(t *testing.T) {
+	ctx := newContext(context.Background())
+
+	got, gotErr := InjectTraceContext(ctx, map[string]any{"message": "hello"})
+	if diff := cmp.Diff(`{"message":"hello"}`, string(got)); diff != "" {
+		t.Errorf("InjectTraceContext() = unexpected result (-want +got)\n%s\n", diff)
+	}
+	if gotErr != nil {
+		t.Errorf("InjectTraceContext() = unexpected error, want: nil, got: %v\n", gotErr)
+	}
+}
+
+type testSpanContextKey struct{}
+
+// testSpan is a Span that records how it was started and ended.
+type testSpan struct {
+	carrier map[string]string
+	info    FunctionInfo
+	err     string
+	ended   bool
+}
+
+func (s *testSpan) End(err error) {
+	if err != nil {
+		s.err = err.Error()
+	}
+	s.ended = true
+}
+
+// testTracer is a Tracer that records the started spans, and injects
+// the name of the function of the span as trace context.
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, carrier map[string]string, info SpanInfo) (context.Context, Span) {
+	s := &testSpan{carrier: carrier, info: info.FunctionInfo}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, testSpanContextKey{}, info.Name), s
+}
+
+func (t *testTracer) Inject(ctx context.Context) map[string]string {
+	name, ok := ctx.Value(testSpanContextKey{}).(string)
+	if !ok {
+		return map[string]string{}
+	}
+	return map[string]string{"span": name}
+}
+
+// testTraceContextTracer is a Tracer that injects a fixed trace context.
+type testTraceContextTracer map[string]string
+
+func (t testTraceContextTracer) Start(ctx context.Context, carrier map[string]string, info SpanInfo) (context.Context, Span) {
+	return ctx, &testSpan{}
+}
+
+func (t testTraceContextTracer) Inject(ctx context.Context) map[string]string {
+	return t
+}
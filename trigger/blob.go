@@ -0,0 +1,149 @@
+package trigger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+)
+
+// Blob represents a Blob Storage trigger.
+type Blob struct {
+	Metadata BlobMetadata
+	Data     data.Raw
+}
+
+// BlobOptions contains options for a Blob Storage trigger.
+type BlobOptions struct {
+	// Path sets the container and blob name pattern to monitor
+	// (function.json).
+	Path string
+	// Connection sets the name of the setting that contains the
+	// connection to the storage account (function.json).
+	Connection string
+}
+
+// BlobOption is a function that sets options on a Blob Storage trigger.
+type BlobOption func(o *BlobOptions)
+
+// BlobMetadata represents the metadata for a Blob Storage trigger.
+type BlobMetadata struct {
+	// BlobTrigger is the path of the triggering blob, including
+	// the container.
+	BlobTrigger string
+	// URI is the URI of the triggering blob.
+	URI string `json:"Uri"`
+	// Properties contains the properties of the triggering blob.
+	Properties BlobProperties
+	// UserMetadata contains the user defined metadata of the
+	// triggering blob.
+	UserMetadata map[string]string `json:"Metadata"`
+	// Parameters contains the values of the parameters
+	// in the path pattern of the trigger, for example {name}.
+	Parameters map[string]string `json:"-"`
+	Metadata
+}
+
+// BlobProperties represents the properties of the blob for a Blob
+// Storage trigger.
+type BlobProperties struct {
+	LastModified    time.Time
+	CreatedOn       time.Time
+	BlobType        string
+	ContentType     string
+	ContentEncoding string
+	ContentLanguage string
+	ContentMD5      string
+	ETag            string
+	ContentLength   int64
+}
+
+// Parse the data of the Blob Storage trigger into the provided
+// value.
+func (t Blob) Parse(v any) error {
+	return json.Unmarshal(t.Data, &v)
+}
+
+// NewBlob creates and returns a new Blob Storage trigger from the
+// provided *http.Request.
+func NewBlob(r *http.Request, name string, options ...BlobOption) (*Blob, error) {
+	opts := BlobOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t blobTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	var metadata BlobMetadata
+	if len(t.Metadata) > 0 {
+		if err := json.Unmarshal(t.Metadata, &metadata); err != nil {
+			return nil, ErrTriggerPayloadMalformed
+		}
+		parameters, err := blobParameters(t.Metadata)
+		if err != nil {
+			return nil, ErrTriggerPayloadMalformed
+		}
+		metadata.Parameters = parameters
+	}
+
+	metadata.BlobTrigger = strings.Trim(metadata.BlobTrigger, "\"")
+	metadata.URI = strings.Trim(metadata.URI, "\"")
+	metadata.Properties.ETag = strings.Trim(metadata.Properties.ETag, "\"")
+
+	return &Blob{
+		Data:     d,
+		Metadata: metadata,
+	}, nil
+}
+
+// blobTrigger is the incoming request from the Function host.
+type blobTrigger struct {
+	Data     map[string]data.Raw
+	Metadata json.RawMessage
+}
+
+// blobMetadataFields contains the fields of the metadata that are
+// not parameters from the path pattern of the trigger.
+var blobMetadataFields = map[string]struct{}{
+	"BlobTrigger": {},
+	"Uri":         {},
+	"Properties":  {},
+	"Metadata":    {},
+	"sys":         {},
+}
+
+// blobParameters returns the parameters from the path pattern
+// of the trigger from the provided metadata.
+func blobParameters(b []byte) (map[string]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	var parameters map[string]string
+	for k, v := range fields {
+		if _, ok := blobMetadataFields[k]; ok {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			continue
+		}
+		if parameters == nil {
+			parameters = make(map[string]string)
+		}
+		parameters[k] = strings.Trim(s, "\"")
+	}
+	return parameters, nil
+}
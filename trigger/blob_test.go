@@ -0,0 +1,131 @@
+package trigger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewBlob(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			req     *http.Request
+			name    string
+			options []BlobOption
+		}
+		want    *Blob
+		wantErr error
+	}{
+		{
+			name: "NewBlob",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []BlobOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(blobRequest1)),
+				},
+				name: "blob",
+			},
+			want: &Blob{
+				Data: data.Raw(`{"message":"hello","number":2}`),
+				Metadata: BlobMetadata{
+					BlobTrigger: "samples/hello.json",
+					URI:         "https://account.blob.core.windows.net/samples/hello.json",
+					Properties: BlobProperties{
+						LastModified:  _testBlobTime1,
+						CreatedOn:     _testBlobTime1,
+						BlobType:      "Block",
+						ContentType:   "application/json",
+						ContentMD5:    "RJzqdr1B0Zy0TfMfNzQ2Ag==",
+						ETag:          "0x8DBCB5A4F7E0C2A",
+						ContentLength: 30,
+					},
+					UserMetadata: map[string]string{
+						"author": "azfunc",
+					},
+					Parameters: map[string]string{
+						"name": "hello",
+					},
+					Metadata: Metadata{
+						Sys: MetadataSys{
+							MethodName: "helloBlob",
+							UTCNow:     _testBlobTime2,
+							RandGuid:   "4e773554-f6b7-4ea2-b07d-4c5fd5aba741",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NewBlob - incorrect name",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []BlobOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(blobRequest1)),
+				},
+				name: "file",
+			},
+			want:    nil,
+			wantErr: ErrTriggerNameIncorrect,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewBlob(test.input.req, test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewBlob() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewBlob() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+var blobRequest1 = []byte(`{
+	"Data": {
+		"blob": "{\"message\":\"hello\",\"number\":2}"
+	},
+	"Metadata": {
+		"BlobTrigger": "\"samples/hello.json\"",
+		"Uri": "\"https://account.blob.core.windows.net/samples/hello.json\"",
+		"Properties": {
+			"LastModified": "2023-10-12T20:13:49+00:00",
+			"CreatedOn": "2023-10-12T20:13:49+00:00",
+			"BlobType": "Block",
+			"ContentType": "application/json",
+			"ContentMD5": "RJzqdr1B0Zy0TfMfNzQ2Ag==",
+			"ETag": "\"0x8DBCB5A4F7E0C2A\"",
+			"ContentLength": 30
+		},
+		"Metadata": {
+			"author": "azfunc"
+		},
+		"name": "\"hello\"",
+		"sys": {
+			"MethodName": "helloBlob",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var (
+	_testBlobTime1, _ = time.Parse(time.RFC3339, "2023-10-12T20:13:49+00:00")
+	_testBlobTime2, _ = time.Parse("2006-01-02T15:04:05.999999Z", "2023-10-12T20:13:49.640002Z")
+)
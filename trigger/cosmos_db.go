@@ -0,0 +1,131 @@
+package trigger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/KarlGW/azfunc/data"
+)
+
+// CosmosDB represents a Cosmos DB trigger. It contains the documents
+// from the change feed.
+type CosmosDB struct {
+	// Data contains the documents as a JSON array.
+	Data      data.Raw
+	Metadata  CosmosDBMetadata
+	documents []data.Raw
+}
+
+// CosmosDBOptions contains options for a Cosmos DB trigger.
+type CosmosDBOptions struct {
+	// Connection sets the name of the setting that contains the
+	// connection to the Cosmos DB account (function.json).
+	Connection string
+	// DatabaseName sets the name of the database with the monitored
+	// container (function.json).
+	DatabaseName string
+	// ContainerName sets the name of the monitored container
+	// (function.json).
+	ContainerName string
+	// LeaseContainerName sets the name of the container used to store
+	// leases (function.json).
+	LeaseContainerName string
+	// CreateLeaseContainerIfNotExists sets if the lease container should
+	// be created if it does not exist (function.json).
+	CreateLeaseContainerIfNotExists bool
+}
+
+// CosmosDBOption is a function that sets options on a Cosmos DB trigger.
+type CosmosDBOption func(o *CosmosDBOptions)
+
+// CosmosDBMetadata represents the metadata for a Cosmos DB trigger.
+type CosmosDBMetadata struct {
+	Metadata
+}
+
+// Documents returns the documents of the Cosmos DB trigger.
+func (t CosmosDB) Documents() []data.Raw {
+	return t.documents
+}
+
+// ParseAll parses the documents of the Cosmos DB trigger into the
+// provided value. The value should be a pointer to a slice.
+func (t CosmosDB) ParseAll(v any) error {
+	return json.Unmarshal(t.Data, &v)
+}
+
+// NewCosmosDB creates and returns a new Cosmos DB trigger from the
+// provided *http.Request.
+func NewCosmosDB(r *http.Request, name string, options ...CosmosDBOption) (*CosmosDB, error) {
+	opts := CosmosDBOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t cosmosDBTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	documents, err := cosmosDBDocuments(d)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(documents)
+	if err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+
+	docs := make([]data.Raw, len(documents))
+	for i := range documents {
+		docs[i] = data.Raw(documents[i])
+	}
+
+	return &CosmosDB{
+		Data:      b,
+		Metadata:  t.Metadata,
+		documents: docs,
+	}, nil
+}
+
+// cosmosDBDocuments decodes the documents from the provided data. The
+// documents are either delivered as a JSON array or as a JSON string
+// containing the JSON array.
+func cosmosDBDocuments(b json.RawMessage) ([]json.RawMessage, error) {
+	b, err := unwrapJSONString(b)
+	if err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return []json.RawMessage{}, nil
+	}
+
+	switch b[0] {
+	case '[':
+		var documents []json.RawMessage
+		if err := json.Unmarshal(b, &documents); err != nil {
+			return nil, ErrTriggerPayloadMalformed
+		}
+		return documents, nil
+	case '{':
+		if !json.Valid(b) {
+			return nil, ErrTriggerPayloadMalformed
+		}
+		return []json.RawMessage{b}, nil
+	default:
+		return nil, ErrTriggerPayloadMalformed
+	}
+}
+
+// cosmosDBTrigger is the incoming request from the Function host.
+type cosmosDBTrigger struct {
+	Data     map[string]json.RawMessage
+	Metadata CosmosDBMetadata
+}
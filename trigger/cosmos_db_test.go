@@ -0,0 +1,216 @@
+package trigger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewCosmosDB(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			req     *http.Request
+			name    string
+			options []CosmosDBOption
+		}
+		want    *CosmosDB
+		wantErr error
+	}{
+		{
+			name: "NewCosmosDB",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []CosmosDBOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(cosmosDBRequest1)),
+				},
+				name: "documents",
+			},
+			want: &CosmosDB{
+				Data:     data.Raw(`[{"id":"1","message":"hello"},{"id":"2","message":"world"}]`),
+				Metadata: _testCosmosDBMetadata,
+				documents: []data.Raw{
+					data.Raw(`{"id":"1","message":"hello"}`),
+					data.Raw(`{"id":"2","message":"world"}`),
+				},
+			},
+		},
+		{
+			name: "NewCosmosDB - string encoded",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []CosmosDBOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(cosmosDBRequest2)),
+				},
+				name: "documents",
+			},
+			want: &CosmosDB{
+				Data:     data.Raw(`[{"id":"1","message":"hello"},{"id":"2","message":"world"}]`),
+				Metadata: _testCosmosDBMetadata,
+				documents: []data.Raw{
+					data.Raw(`{"id":"1","message":"hello"}`),
+					data.Raw(`{"id":"2","message":"world"}`),
+				},
+			},
+		},
+		{
+			name: "NewCosmosDB - malformed documents",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []CosmosDBOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(cosmosDBRequest3)),
+				},
+				name: "documents",
+			},
+			want:    nil,
+			wantErr: ErrTriggerPayloadMalformed,
+		},
+		{
+			name: "NewCosmosDB - incorrect name",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []CosmosDBOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(cosmosDBRequest1)),
+				},
+				name: "items",
+			},
+			want:    nil,
+			wantErr: ErrTriggerNameIncorrect,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewCosmosDB(test.input.req, test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(CosmosDB{})); diff != "" {
+				t.Errorf("NewCosmosDB() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewCosmosDB() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCosmosDB_ParseAll(t *testing.T) {
+	type testDocument struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+
+	var tests = []struct {
+		name    string
+		input   []byte
+		want    []testDocument
+		wantErr error
+	}{
+		{
+			name:  "ParseAll",
+			input: cosmosDBRequest1,
+			want: []testDocument{
+				{ID: "1", Message: "hello"},
+				{ID: "2", Message: "world"},
+			},
+		},
+		{
+			name:  "ParseAll - string encoded",
+			input: cosmosDBRequest2,
+			want: []testDocument{
+				{ID: "1", Message: "hello"},
+				{ID: "2", Message: "world"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr, err := NewCosmosDB(&http.Request{Body: io.NopCloser(bytes.NewBuffer(test.input))}, "documents")
+			if err != nil {
+				t.Fatalf("NewCosmosDB() = unexpected error: %v", err)
+			}
+
+			var got []testDocument
+			gotErr := tr.ParseAll(&got)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ParseAll() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("ParseAll() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+var cosmosDBRequest1 = []byte(`{
+	"Data": {
+		"documents": [{"id":"1","message":"hello"},{"id":"2","message":"world"}]
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "helloCosmosDB",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var cosmosDBRequest2 = []byte(`{
+	"Data": {
+		"documents": "[{\"id\":\"1\",\"message\":\"hello\"},{\"id\":\"2\",\"message\":\"world\"}]"
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "helloCosmosDB",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var cosmosDBRequest3 = []byte(`{
+	"Data": {
+		"documents": "hello"
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "helloCosmosDB",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var (
+	_testCosmosDBTime1, _ = time.Parse("2006-01-02T15:04:05.999999Z", "2023-10-12T20:13:49.640002Z")
+	_testCosmosDBMetadata = CosmosDBMetadata{
+		Metadata: Metadata{
+			Sys: MetadataSys{
+				MethodName: "helloCosmosDB",
+				UTCNow:     _testCosmosDBTime1,
+				RandGuid:   "4e773554-f6b7-4ea2-b07d-4c5fd5aba741",
+			},
+		},
+	}
+)
@@ -0,0 +1,184 @@
+package trigger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/KarlGW/azfunc/durable"
+)
+
+// Orchestration represents an orchestration trigger (Durable Functions).
+// It contains the orchestration context used to schedule activities,
+// timers and to wait for external events.
+type Orchestration struct {
+	*durable.OrchestrationContext
+	Metadata OrchestrationMetadata
+}
+
+// OrchestrationOptions contains options for an orchestration trigger.
+type OrchestrationOptions struct{}
+
+// OrchestrationOption is a function that sets options on an orchestration
+// trigger.
+type OrchestrationOption func(o *OrchestrationOptions)
+
+// OrchestrationMetadata represents the metadata for an orchestration
+// trigger.
+type OrchestrationMetadata struct {
+	Metadata
+}
+
+// NewOrchestration creates and returns a new orchestration trigger from
+// the provided *http.Request.
+func NewOrchestration(r *http.Request, name string, options ...OrchestrationOption) (*Orchestration, error) {
+	opts := OrchestrationOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t orchestrationTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	d, err := unwrapJSONString(d)
+	if err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	var req durable.OrchestrationRequest
+	if err := json.Unmarshal(d, &req); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+
+	return &Orchestration{
+		OrchestrationContext: durable.NewOrchestrationContext(req),
+		Metadata:             t.Metadata,
+	}, nil
+}
+
+// orchestrationTrigger is the incoming request from the Function host.
+type orchestrationTrigger struct {
+	Data     map[string]json.RawMessage
+	Metadata OrchestrationMetadata
+}
+
+// Activity represents an activity trigger (Durable Functions). The result
+// of the activity is returned by setting the return value of the outputs.
+// Data contains the input of the activity as JSON.
+type Activity struct {
+	Metadata ActivityMetadata
+	Data     data.Raw
+}
+
+// ActivityOptions contains options for an activity trigger.
+type ActivityOptions struct{}
+
+// ActivityOption is a function that sets options on an activity trigger.
+type ActivityOption func(o *ActivityOptions)
+
+// ActivityMetadata represents the metadata for an activity trigger.
+type ActivityMetadata struct {
+	InstanceID string `json:"instanceId"`
+	Metadata
+}
+
+// Parse the input of the activity trigger into the provided value.
+func (t Activity) Parse(v any) error {
+	return json.Unmarshal(t.Data, &v)
+}
+
+// NewActivity creates and returns a new activity trigger from the
+// provided *http.Request.
+func NewActivity(r *http.Request, name string, options ...ActivityOption) (*Activity, error) {
+	opts := ActivityOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t activityTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	return &Activity{
+		Data:     data.Raw(unquoteJSON(d)),
+		Metadata: t.Metadata,
+	}, nil
+}
+
+// activityTrigger is the incoming request from the Function host.
+type activityTrigger struct {
+	Data     map[string]json.RawMessage
+	Metadata ActivityMetadata
+}
+
+// Entity represents an entity trigger (Durable Functions). It contains
+// the entity context used to read and write the state of the entity.
+type Entity struct {
+	*durable.EntityContext
+	Metadata EntityMetadata
+}
+
+// EntityOptions contains options for an entity trigger.
+type EntityOptions struct{}
+
+// EntityOption is a function that sets options on an entity trigger.
+type EntityOption func(o *EntityOptions)
+
+// EntityMetadata represents the metadata for an entity trigger.
+type EntityMetadata struct {
+	Metadata
+}
+
+// NewEntity creates and returns a new entity trigger from the provided
+// *http.Request.
+func NewEntity(r *http.Request, name string, options ...EntityOption) (*Entity, error) {
+	opts := EntityOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t entityTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	d, err := unwrapJSONString(d)
+	if err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	var req durable.EntityRequest
+	if err := json.Unmarshal(d, &req); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+
+	return &Entity{
+		EntityContext: durable.NewEntityContext(req),
+		Metadata:      t.Metadata,
+	}, nil
+}
+
+// entityTrigger is the incoming request from the Function host.
+type entityTrigger struct {
+	Data     map[string]json.RawMessage
+	Metadata EntityMetadata
+}
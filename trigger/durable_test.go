@@ -0,0 +1,221 @@
+package trigger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/KarlGW/azfunc/durable"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewOrchestration(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			req     *http.Request
+			name    string
+			options []OrchestrationOption
+		}
+		want    durable.OrchestratorState
+		wantErr error
+	}{
+		{
+			name: "NewOrchestration",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []OrchestrationOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(orchestrationRequest1)),
+				},
+				name: "context",
+			},
+			want: durable.OrchestratorState{
+				Actions: [][]durable.Action{
+					{{ActionType: durable.ActionTypeCallActivity, FunctionName: "SayHello", Input: "Tokyo"}},
+				},
+				Output: "Hello Tokyo!",
+				IsDone: true,
+			},
+		},
+		{
+			name: "NewOrchestration - incorrect name",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []OrchestrationOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(orchestrationRequest1)),
+				},
+				name: "orchestration",
+			},
+			wantErr: ErrTriggerNameIncorrect,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr, gotErr := NewOrchestration(test.input.req, test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewOrchestration() = unexpected error (-want +got)\n%s\n", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+
+			if tr.InstanceID() != "7b8b4a1a3f8b4b2d9c6c2a1b0e9f8d7c" {
+				t.Errorf("InstanceID() = unexpected result, got: %s", tr.InstanceID())
+			}
+
+			got := tr.Run(func() error {
+				var name, greeting string
+				if err := tr.Input(&name); err != nil {
+					return err
+				}
+				if err := tr.CallActivity("SayHello", name).Await(&greeting); err != nil {
+					return err
+				}
+				tr.SetOutput(greeting)
+				return nil
+			})
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewOrchestration() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNewActivity(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			req     *http.Request
+			name    string
+			options []ActivityOption
+		}
+		want    *Activity
+		wantErr error
+	}{
+		{
+			name: "NewActivity",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []ActivityOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(activityRequest1)),
+				},
+				name: "name",
+			},
+			want: &Activity{
+				Data: data.Raw(`"Tokyo"`),
+				Metadata: ActivityMetadata{
+					InstanceID: "7b8b4a1a3f8b4b2d9c6c2a1b0e9f8d7c",
+					Metadata: Metadata{
+						Sys: MetadataSys{
+							MethodName: "SayHello",
+							UTCNow:     _testDurableTime1,
+							RandGuid:   "4e773554-f6b7-4ea2-b07d-4c5fd5aba741",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewActivity(test.input.req, test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewActivity() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewActivity() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNewEntity(t *testing.T) {
+	tr, err := NewEntity(&http.Request{Body: io.NopCloser(bytes.NewBuffer(entityRequest1))}, "context")
+	if err != nil {
+		t.Fatalf("NewEntity() = unexpected error: %v", err)
+	}
+
+	got := tr.Run(func() error {
+		var n, amount int
+		if err := tr.State(&n); err != nil {
+			return err
+		}
+		if err := tr.Input(&amount); err != nil {
+			return err
+		}
+		return tr.SetState(n + amount)
+	})
+
+	state := "8"
+	want := durable.EntityState{
+		EntityExists: true,
+		EntityState:  &state,
+		Results:      []durable.EntityOperationResult{{}},
+		Signals:      []durable.EntitySignal{},
+	}
+
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(durable.EntityOperationResult{}, "Duration")); diff != "" {
+		t.Errorf("NewEntity() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+var orchestrationRequest1 = []byte(`{
+	"Data": {
+		"context": "{\"history\":[{\"EventType\":12,\"EventId\":-1,\"IsPlayed\":true,\"Timestamp\":\"2023-10-12T20:13:49.640002Z\"},{\"EventType\":0,\"EventId\":-1,\"IsPlayed\":true,\"Timestamp\":\"2023-10-12T20:13:49.640002Z\",\"Name\":\"HelloSequence\",\"Input\":\"\\\"Tokyo\\\"\"},{\"EventType\":4,\"EventId\":0,\"IsPlayed\":true,\"Timestamp\":\"2023-10-12T20:13:49.640002Z\",\"Name\":\"SayHello\"},{\"EventType\":13,\"EventId\":-1,\"IsPlayed\":true,\"Timestamp\":\"2023-10-12T20:13:49.640002Z\"},{\"EventType\":12,\"EventId\":-1,\"IsPlayed\":false,\"Timestamp\":\"2023-10-12T20:13:50.640002Z\"},{\"EventType\":5,\"EventId\":-1,\"IsPlayed\":false,\"Timestamp\":\"2023-10-12T20:13:50.640002Z\",\"TaskScheduledId\":0,\"Result\":\"\\\"Hello Tokyo!\\\"\"}],\"input\":\"Tokyo\",\"instanceId\":\"7b8b4a1a3f8b4b2d9c6c2a1b0e9f8d7c\",\"isReplaying\":true,\"parentInstanceId\":null}"
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "HelloSequence",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var activityRequest1 = []byte(`{
+	"Data": {
+		"name": "\"Tokyo\""
+	},
+	"Metadata": {
+		"instanceId": "7b8b4a1a3f8b4b2d9c6c2a1b0e9f8d7c",
+		"sys": {
+			"MethodName": "SayHello",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var entityRequest1 = []byte(`{
+	"Data": {
+		"context": "{\"self\":{\"name\":\"counter\",\"key\":\"1\"},\"exists\":true,\"state\":\"5\",\"batch\":[{\"id\":\"1\",\"name\":\"add\",\"signal\":true,\"input\":\"3\"}]}"
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "Counter",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var _testDurableTime1, _ = time.Parse("2006-01-02T15:04:05.999999Z", "2023-10-12T20:13:49.640002Z")
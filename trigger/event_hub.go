@@ -0,0 +1,182 @@
+package trigger
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+)
+
+// EventHub represents an Event Hub trigger. It supports both single
+// events and batches of events (cardinality many).
+type EventHub struct {
+	// Data contains the data as delivered by the Function host. In case
+	// of a batch it contains all events.
+	Data data.Raw
+	// Events contains the events of the trigger, together with their
+	// metadata. For a single event it contains one event.
+	Events   []EventHubEvent
+	Metadata EventHubMetadata
+}
+
+// EventHubOptions contains options for an Event Hub trigger.
+type EventHubOptions struct {
+	// EventHubName sets the name of the event hub (function.json).
+	EventHubName string
+	// Connection sets the name of the setting that contains the
+	// connection to the Event Hub namespace (function.json).
+	Connection string
+	// ConsumerGroup sets the consumer group used to subscribe to
+	// events (function.json).
+	ConsumerGroup string
+	// Cardinality sets if events are received in batches ("many")
+	// or one at a time ("one") (function.json).
+	Cardinality string
+}
+
+// EventHubOption is a function that sets options on an Event Hub trigger.
+type EventHubOption func(o *EventHubOptions)
+
+// EventHubEvent represents a single event of an Event Hub trigger together
+// with its metadata.
+type EventHubEvent struct {
+	EnqueuedTimeUTC  time.Time
+	Properties       map[string]any
+	SystemProperties map[string]any
+	Offset           string
+	PartitionKey     string
+	Data             data.Raw
+	SequenceNumber   int64
+}
+
+// EventHubMetadata represents the metadata for an Event Hub trigger. The
+// fields without the Array suffix are set for single events, and the fields
+// with the Array suffix are set for batches of events.
+type EventHubMetadata struct {
+	PartitionContext      EventHubPartitionContext
+	EnqueuedTimeUTC       time.Time `json:"EnqueuedTimeUtc"`
+	Properties            map[string]any
+	SystemProperties      map[string]any
+	Offset                string
+	PartitionKey          string
+	SequenceNumber        int64
+	EnqueuedTimeUTCArray  []time.Time `json:"EnqueuedTimeUtcArray"`
+	PropertiesArray       []map[string]any
+	SystemPropertiesArray []map[string]any
+	OffsetArray           []string
+	PartitionKeyArray     []string
+	SequenceNumberArray   []int64
+	Metadata
+}
+
+// EventHubPartitionContext represents the partition context of the
+// Event Hub trigger metadata.
+type EventHubPartitionContext struct {
+	ConsumerGroup           string
+	EventHubName            string
+	FullyQualifiedNamespace string
+	PartitionID             string `json:"PartitionId"`
+}
+
+// Parse the data of the Event Hub trigger into the provided value.
+// In case of a batch, the provided value should be a slice.
+func (t EventHub) Parse(v any) error {
+	return json.Unmarshal(t.Data, &v)
+}
+
+// Parse the data of the event into the provided value.
+func (e EventHubEvent) Parse(v any) error {
+	return json.Unmarshal(e.Data, &v)
+}
+
+// NewEventHub creates and returns a new Event Hub trigger from the
+// provided *http.Request.
+func NewEventHub(r *http.Request, name string, options ...EventHubOption) (*EventHub, error) {
+	opts := EventHubOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t eventHubTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	var events []EventHubEvent
+	if t.Metadata.isBatch() {
+		var err error
+		events, err = eventHubEvents(d, t.Metadata)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		events = []EventHubEvent{
+			{
+				EnqueuedTimeUTC:  t.Metadata.EnqueuedTimeUTC,
+				Properties:       t.Metadata.Properties,
+				SystemProperties: t.Metadata.SystemProperties,
+				Offset:           t.Metadata.Offset,
+				PartitionKey:     t.Metadata.PartitionKey,
+				Data:             d,
+				SequenceNumber:   t.Metadata.SequenceNumber,
+			},
+		}
+	}
+
+	return &EventHub{
+		Data:     d,
+		Events:   events,
+		Metadata: t.Metadata,
+	}, nil
+}
+
+// isBatch returns true if the metadata is for a batch of events.
+func (m EventHubMetadata) isBatch() bool {
+	return m.OffsetArray != nil || m.SequenceNumberArray != nil || m.EnqueuedTimeUTCArray != nil
+}
+
+// eventHubEvents zips the batch data together with the metadata
+// arrays and returns them as events.
+func eventHubEvents(d data.Raw, m EventHubMetadata) ([]EventHubEvent, error) {
+	var raw []data.Raw
+	if err := json.Unmarshal(d, &raw); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+
+	events := make([]EventHubEvent, len(raw))
+	for i := range raw {
+		events[i].Data = raw[i]
+		if i < len(m.EnqueuedTimeUTCArray) {
+			events[i].EnqueuedTimeUTC = m.EnqueuedTimeUTCArray[i]
+		}
+		if i < len(m.PropertiesArray) {
+			events[i].Properties = m.PropertiesArray[i]
+		}
+		if i < len(m.SystemPropertiesArray) {
+			events[i].SystemProperties = m.SystemPropertiesArray[i]
+		}
+		if i < len(m.OffsetArray) {
+			events[i].Offset = m.OffsetArray[i]
+		}
+		if i < len(m.PartitionKeyArray) {
+			events[i].PartitionKey = m.PartitionKeyArray[i]
+		}
+		if i < len(m.SequenceNumberArray) {
+			events[i].SequenceNumber = m.SequenceNumberArray[i]
+		}
+	}
+	return events, nil
+}
+
+// eventHubTrigger is the incoming request from the Function host.
+type eventHubTrigger struct {
+	Data     map[string]data.Raw
+	Metadata EventHubMetadata
+}
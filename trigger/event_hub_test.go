@@ -0,0 +1,248 @@
+package trigger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewEventHub(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			req     *http.Request
+			name    string
+			options []EventHubOption
+		}
+		want    *EventHub
+		wantErr error
+	}{
+		{
+			name: "NewEventHub",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []EventHubOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(eventHubRequest1)),
+				},
+				name: "events",
+			},
+			want: &EventHub{
+				Data: data.Raw(`{"message":"hello","number":1}`),
+				Events: []EventHubEvent{
+					{
+						EnqueuedTimeUTC:  _testEventHubTime1,
+						Properties:       map[string]any{"source": "device1"},
+						SystemProperties: map[string]any{"x-opt-sequence-number": float64(12)},
+						Offset:           "8589934592",
+						PartitionKey:     "key1",
+						Data:             data.Raw(`{"message":"hello","number":1}`),
+						SequenceNumber:   12,
+					},
+				},
+				Metadata: EventHubMetadata{
+					PartitionContext: _testEventHubPartitionContext,
+					EnqueuedTimeUTC:  _testEventHubTime1,
+					Properties:       map[string]any{"source": "device1"},
+					SystemProperties: map[string]any{"x-opt-sequence-number": float64(12)},
+					Offset:           "8589934592",
+					PartitionKey:     "key1",
+					SequenceNumber:   12,
+					Metadata: Metadata{
+						Sys: MetadataSys{
+							MethodName: "helloEventHub",
+							UTCNow:     _testEventHubTime1,
+							RandGuid:   "4e773554-f6b7-4ea2-b07d-4c5fd5aba741",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NewEventHub - batch",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []EventHubOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(eventHubRequest2)),
+				},
+				name: "events",
+			},
+			want: &EventHub{
+				Data: data.Raw(`[{"message":"hello","number":1},"{\"message\":\"hello\",\"number\":2}"]`),
+				Events: []EventHubEvent{
+					{
+						EnqueuedTimeUTC:  _testEventHubTime1,
+						Properties:       map[string]any{"source": "device1"},
+						SystemProperties: map[string]any{"x-opt-sequence-number": float64(12)},
+						Offset:           "8589934592",
+						PartitionKey:     "key1",
+						Data:             data.Raw(`{"message":"hello","number":1}`),
+						SequenceNumber:   12,
+					},
+					{
+						EnqueuedTimeUTC:  _testEventHubTime2,
+						Properties:       map[string]any{"source": "device2"},
+						SystemProperties: map[string]any{"x-opt-sequence-number": float64(13)},
+						Offset:           "8589935184",
+						PartitionKey:     "key2",
+						Data:             data.Raw(`{"message":"hello","number":2}`),
+						SequenceNumber:   13,
+					},
+				},
+				Metadata: EventHubMetadata{
+					PartitionContext:     _testEventHubPartitionContext,
+					EnqueuedTimeUTCArray: []time.Time{_testEventHubTime1, _testEventHubTime2},
+					PropertiesArray: []map[string]any{
+						{"source": "device1"},
+						{"source": "device2"},
+					},
+					SystemPropertiesArray: []map[string]any{
+						{"x-opt-sequence-number": float64(12)},
+						{"x-opt-sequence-number": float64(13)},
+					},
+					OffsetArray:         []string{"8589934592", "8589935184"},
+					PartitionKeyArray:   []string{"key1", "key2"},
+					SequenceNumberArray: []int64{12, 13},
+					Metadata: Metadata{
+						Sys: MetadataSys{
+							MethodName: "helloEventHub",
+							UTCNow:     _testEventHubTime1,
+							RandGuid:   "4e773554-f6b7-4ea2-b07d-4c5fd5aba741",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "NewEventHub - incorrect name",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []EventHubOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(eventHubRequest1)),
+				},
+				name: "event",
+			},
+			want:    nil,
+			wantErr: ErrTriggerNameIncorrect,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewEventHub(test.input.req, test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewEventHub() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewEventHub() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestEventHub_Parse(t *testing.T) {
+	type testData struct {
+		Message string `json:"message"`
+		Number  int    `json:"number"`
+	}
+
+	tr, err := NewEventHub(&http.Request{Body: io.NopCloser(bytes.NewBuffer(eventHubRequest2))}, "events")
+	if err != nil {
+		t.Fatalf("NewEventHub() = unexpected error: %v", err)
+	}
+
+	want := []testData{{Message: "hello", Number: 1}, {Message: "hello", Number: 2}}
+	var got []testData
+	for _, event := range tr.Events {
+		var d testData
+		if err := event.Parse(&d); err != nil {
+			t.Fatalf("Parse() = unexpected error: %v", err)
+		}
+		got = append(got, d)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Parse() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+var eventHubRequest1 = []byte(`{
+	"Data": {
+		"events": "{\"message\":\"hello\",\"number\":1}"
+	},
+	"Metadata": {
+		"PartitionContext": {
+			"FullyQualifiedNamespace": "namespace.servicebus.windows.net",
+			"EventHubName": "hub",
+			"ConsumerGroup": "$Default",
+			"PartitionId": "0"
+		},
+		"EnqueuedTimeUtc": "2023-10-12T20:13:49.640002Z",
+		"Offset": "8589934592",
+		"PartitionKey": "key1",
+		"SequenceNumber": 12,
+		"Properties": {
+			"source": "device1"
+		},
+		"SystemProperties": {
+			"x-opt-sequence-number": 12
+		},
+		"sys": {
+			"MethodName": "helloEventHub",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var eventHubRequest2 = []byte(`{
+	"Data": {
+		"events": [{"message":"hello","number":1},"{\"message\":\"hello\",\"number\":2}"]
+	},
+	"Metadata": {
+		"PartitionContext": {
+			"FullyQualifiedNamespace": "namespace.servicebus.windows.net",
+			"EventHubName": "hub",
+			"ConsumerGroup": "$Default",
+			"PartitionId": "0"
+		},
+		"EnqueuedTimeUtcArray": ["2023-10-12T20:13:49.640002Z", "2023-10-12T20:13:50.640002Z"],
+		"OffsetArray": ["8589934592", "8589935184"],
+		"PartitionKeyArray": ["key1", "key2"],
+		"SequenceNumberArray": [12, 13],
+		"PropertiesArray": [{"source": "device1"}, {"source": "device2"}],
+		"SystemPropertiesArray": [{"x-opt-sequence-number": 12}, {"x-opt-sequence-number": 13}],
+		"sys": {
+			"MethodName": "helloEventHub",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var (
+	_testEventHubTime1, _         = time.Parse("2006-01-02T15:04:05.999999Z", "2023-10-12T20:13:49.640002Z")
+	_testEventHubTime2, _         = time.Parse("2006-01-02T15:04:05.999999Z", "2023-10-12T20:13:50.640002Z")
+	_testEventHubPartitionContext = EventHubPartitionContext{
+		ConsumerGroup:           "$Default",
+		EventHubName:            "hub",
+		FullyQualifiedNamespace: "namespace.servicebus.windows.net",
+		PartitionID:             "0",
+	}
+)
@@ -15,7 +15,13 @@ type Generic struct {
 }
 
 // GenericOptions contains options for a Generic trigger.
-type GenericOptions struct{}
+type GenericOptions struct {
+	// Type sets the type of the trigger (function.json).
+	Type string
+	// Properties sets additional properties of the trigger
+	// (function.json).
+	Properties map[string]any
+}
 
 // GenericOption is a function that sets options on a Generic trigger.
 type GenericOption func(o *GenericOptions)
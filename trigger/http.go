@@ -41,6 +41,14 @@ type HTTP struct {
 // HTTPOptions contains options for an HTTP trigger.
 type HTTPOptions struct {
 	Name string
+	// AuthLevel sets the authorization level of the trigger. Defaults
+	// to "function" (function.json).
+	AuthLevel string
+	// Route sets the route template of the trigger (function.json).
+	Route string
+	// Methods sets the HTTP methods the trigger responds to
+	// (function.json).
+	Methods []string
 }
 
 // HTTPOption is a function that sets options on an HTTP trigger.
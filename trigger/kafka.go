@@ -0,0 +1,206 @@
+package trigger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+)
+
+// Kafka represents a Kafka trigger. It supports both single records
+// and batches of records (cardinality many).
+type Kafka struct {
+	// Data contains the data as delivered by the Function host.
+	Data data.Raw
+	// Records contains the records of the trigger. For a single
+	// record it contains one record.
+	Records  []KafkaRecord
+	Metadata KafkaMetadata
+}
+
+// KafkaOptions contains options for a Kafka trigger.
+type KafkaOptions struct {
+	// Binary sets if the keys and values of the records are
+	// base64 encoded binary data (dataType binary). If set they
+	// will be decoded.
+	Binary bool
+	// BrokerList sets the name of the setting that contains the
+	// list of brokers (function.json).
+	BrokerList string
+	// Topic sets the topic to monitor (function.json).
+	Topic string
+	// ConsumerGroup sets the consumer group used by the trigger
+	// (function.json).
+	ConsumerGroup string
+	// Cardinality sets if records are received in batches ("many")
+	// or one at a time ("one") (function.json).
+	Cardinality string
+}
+
+// KafkaOption is a function that sets options on a Kafka trigger.
+type KafkaOption func(o *KafkaOptions)
+
+// KafkaRecord represents a Kafka record.
+type KafkaRecord struct {
+	Timestamp time.Time
+	Topic     string
+	Key       data.Raw
+	Value     data.Raw
+	Headers   []KafkaHeader
+	Offset    int64
+	Partition int32
+}
+
+// KafkaHeader represents a header of a Kafka record. The value
+// is decoded from its base64 form.
+type KafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+// KafkaMetadata represents the metadata for a Kafka trigger.
+type KafkaMetadata struct {
+	Metadata
+}
+
+// Parse the value of the Kafka record into the provided value.
+func (r KafkaRecord) Parse(v any) error {
+	return json.Unmarshal(r.Value, &v)
+}
+
+// Header returns the value of the header with the provided key. If
+// there are several headers with the same key, the first is returned.
+func (r KafkaRecord) Header(key string) ([]byte, bool) {
+	for _, header := range r.Headers {
+		if header.Key == key {
+			return header.Value, true
+		}
+	}
+	return nil, false
+}
+
+// NewKafka creates and returns a new Kafka trigger from the provided
+// *http.Request.
+func NewKafka(r *http.Request, name string, options ...KafkaOption) (*Kafka, error) {
+	opts := KafkaOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var t kafkaTrigger
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+	defer r.Body.Close()
+
+	d, ok := t.Data[name]
+	if !ok {
+		return nil, ErrTriggerNameIncorrect
+	}
+
+	d, err := unwrapJSONString(d)
+	if err != nil {
+		return nil, ErrTriggerPayloadMalformed
+	}
+
+	var raw []json.RawMessage
+	if len(d) > 0 && d[0] == '[' {
+		if err := json.Unmarshal(d, &raw); err != nil {
+			return nil, ErrTriggerPayloadMalformed
+		}
+	} else {
+		raw = []json.RawMessage{d}
+	}
+
+	records := make([]KafkaRecord, len(raw))
+	for i := range raw {
+		record, err := newKafkaRecord(raw[i], opts.Binary)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+
+	return &Kafka{
+		Data:     data.Raw(d),
+		Records:  records,
+		Metadata: t.Metadata,
+	}, nil
+}
+
+// newKafkaRecord creates a KafkaRecord from the provided JSON.
+func newKafkaRecord(b json.RawMessage, binary bool) (KafkaRecord, error) {
+	b, err := unwrapJSONString(b)
+	if err != nil {
+		return KafkaRecord{}, ErrTriggerPayloadMalformed
+	}
+
+	var record kafkaRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		return KafkaRecord{}, ErrTriggerPayloadMalformed
+	}
+
+	key, err := kafkaValue(record.Key, binary)
+	if err != nil {
+		return KafkaRecord{}, ErrTriggerPayloadMalformed
+	}
+	value, err := kafkaValue(record.Value, binary)
+	if err != nil {
+		return KafkaRecord{}, ErrTriggerPayloadMalformed
+	}
+
+	return KafkaRecord{
+		Timestamp: record.Timestamp,
+		Topic:     record.Topic,
+		Key:       key,
+		Value:     value,
+		Headers:   record.Headers,
+		Offset:    record.Offset,
+		Partition: record.Partition,
+	}, nil
+}
+
+// kafkaValue returns the key or value of a Kafka record. JSON strings
+// are unquoted and decoded from base64 if binary is set, other JSON
+// values are returned as is.
+func kafkaValue(b json.RawMessage, binary bool) (data.Raw, error) {
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return nil, nil
+	}
+	if b[0] != '"' {
+		return data.Raw(b), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	if binary {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return data.Raw(decoded), nil
+	}
+	return data.Raw(s), nil
+}
+
+// kafkaTrigger is the incoming request from the Function host.
+type kafkaTrigger struct {
+	Data     map[string]json.RawMessage
+	Metadata KafkaMetadata
+}
+
+// kafkaRecord is a record from the Function host.
+type kafkaRecord struct {
+	Timestamp time.Time
+	Topic     string
+	Key       json.RawMessage
+	Value     json.RawMessage
+	Headers   []KafkaHeader
+	Offset    int64
+	Partition int32
+}
@@ -0,0 +1,206 @@
+package trigger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KarlGW/azfunc/data"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewKafka(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			req     *http.Request
+			name    string
+			options []KafkaOption
+		}
+		want    *Kafka
+		wantErr error
+	}{
+		{
+			name: "NewKafka",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []KafkaOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(kafkaRequest1)),
+				},
+				name: "kevent",
+			},
+			want: &Kafka{
+				Data: data.Raw(`{"Offset":364,"Partition":0,"Topic":"topic","Timestamp":"2023-10-12T20:13:49.64Z","Key":"key1","Value":"{\"message\":\"hello\"}","Headers":[{"Key":"source","Value":"YXpmdW5j"}]}`),
+				Records: []KafkaRecord{
+					{
+						Timestamp: _testKafkaTime1,
+						Topic:     "topic",
+						Key:       data.Raw(`key1`),
+						Value:     data.Raw(`{"message":"hello"}`),
+						Headers: []KafkaHeader{
+							{Key: "source", Value: []byte("azfunc")},
+						},
+						Offset:    364,
+						Partition: 0,
+					},
+				},
+				Metadata: _testKafkaMetadata,
+			},
+		},
+		{
+			name: "NewKafka - batch",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []KafkaOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(kafkaRequest2)),
+				},
+				name: "kevent",
+			},
+			want: &Kafka{
+				Data: data.Raw(`["{\"Offset\":364,\"Partition\":0,\"Topic\":\"topic\",\"Timestamp\":\"2023-10-12T20:13:49.64Z\",\"Value\":\"hello\",\"Headers\":[]}","{\"Offset\":365,\"Partition\":1,\"Topic\":\"topic\",\"Timestamp\":\"2023-10-12T20:13:49.64Z\",\"Value\":\"world\",\"Headers\":[]}"]`),
+				Records: []KafkaRecord{
+					{
+						Timestamp: _testKafkaTime1,
+						Topic:     "topic",
+						Value:     data.Raw(`hello`),
+						Headers:   []KafkaHeader{},
+						Offset:    364,
+						Partition: 0,
+					},
+					{
+						Timestamp: _testKafkaTime1,
+						Topic:     "topic",
+						Value:     data.Raw(`world`),
+						Headers:   []KafkaHeader{},
+						Offset:    365,
+						Partition: 1,
+					},
+				},
+				Metadata: _testKafkaMetadata,
+			},
+		},
+		{
+			name: "NewKafka - binary",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []KafkaOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(kafkaRequest3)),
+				},
+				name: "kevent",
+				options: []KafkaOption{
+					func(o *KafkaOptions) {
+						o.Binary = true
+					},
+				},
+			},
+			want: &Kafka{
+				Data: data.Raw(`{"Offset":364,"Partition":0,"Topic":"topic","Timestamp":"2023-10-12T20:13:49.64Z","Key":"a2V5MQ==","Value":"aGVsbG8="}`),
+				Records: []KafkaRecord{
+					{
+						Timestamp: _testKafkaTime1,
+						Topic:     "topic",
+						Key:       data.Raw(`key1`),
+						Value:     data.Raw(`hello`),
+						Offset:    364,
+						Partition: 0,
+					},
+				},
+				Metadata: _testKafkaMetadata,
+			},
+		},
+		{
+			name: "NewKafka - incorrect name",
+			input: struct {
+				req     *http.Request
+				name    string
+				options []KafkaOption
+			}{
+				req: &http.Request{
+					Body: io.NopCloser(bytes.NewBuffer(kafkaRequest1)),
+				},
+				name: "event",
+			},
+			want:    nil,
+			wantErr: ErrTriggerNameIncorrect,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewKafka(test.input.req, test.input.name, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("NewKafka() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("NewKafka() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+var kafkaRequest1 = []byte(`{
+	"Data": {
+		"kevent": "{\"Offset\":364,\"Partition\":0,\"Topic\":\"topic\",\"Timestamp\":\"2023-10-12T20:13:49.64Z\",\"Key\":\"key1\",\"Value\":\"{\\\"message\\\":\\\"hello\\\"}\",\"Headers\":[{\"Key\":\"source\",\"Value\":\"YXpmdW5j\"}]}"
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "helloKafka",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var kafkaRequest2 = []byte(`{
+	"Data": {
+		"kevent": ["{\"Offset\":364,\"Partition\":0,\"Topic\":\"topic\",\"Timestamp\":\"2023-10-12T20:13:49.64Z\",\"Value\":\"hello\",\"Headers\":[]}","{\"Offset\":365,\"Partition\":1,\"Topic\":\"topic\",\"Timestamp\":\"2023-10-12T20:13:49.64Z\",\"Value\":\"world\",\"Headers\":[]}"]
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "helloKafka",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var kafkaRequest3 = []byte(`{
+	"Data": {
+		"kevent": "{\"Offset\":364,\"Partition\":0,\"Topic\":\"topic\",\"Timestamp\":\"2023-10-12T20:13:49.64Z\",\"Key\":\"a2V5MQ==\",\"Value\":\"aGVsbG8=\"}"
+	},
+	"Metadata": {
+		"sys": {
+			"MethodName": "helloKafka",
+			"UtcNow": "2023-10-12T20:13:49.640002Z",
+			"RandGuid": "4e773554-f6b7-4ea2-b07d-4c5fd5aba741"
+		}
+	}
+}`)
+
+var (
+	_testKafkaTime1, _ = time.Parse(time.RFC3339Nano, "2023-10-12T20:13:49.64Z")
+	_testKafkaTime2, _ = time.Parse("2006-01-02T15:04:05.999999Z", "2023-10-12T20:13:49.640002Z")
+	_testKafkaMetadata = KafkaMetadata{
+		Metadata: Metadata{
+			Sys: MetadataSys{
+				MethodName: "helloKafka",
+				UTCNow:     _testKafkaTime2,
+				RandGuid:   "4e773554-f6b7-4ea2-b07d-4c5fd5aba741",
+			},
+		},
+	}
+)
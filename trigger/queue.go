@@ -16,7 +16,13 @@ type Queue struct {
 }
 
 // QueueOptions contains options for a Queue Storage trigger.
-type QueueOptions struct{}
+type QueueOptions struct {
+	// QueueName sets the name of the queue to monitor (function.json).
+	QueueName string
+	// Connection sets the name of the setting that contains the
+	// connection to the storage account (function.json).
+	Connection string
+}
 
 // QueueOption is a function that sets options on a Queue Storage trigger.
 type QueueOption func(o *QueueOptions)
@@ -16,7 +16,18 @@ type ServiceBus struct {
 }
 
 // ServiceBusOptions contains options for a Service Bus trigger.
-type ServiceBusOptions struct{}
+type ServiceBusOptions struct {
+	// QueueName sets the name of the queue to monitor (function.json).
+	QueueName string
+	// TopicName sets the name of the topic to monitor (function.json).
+	TopicName string
+	// SubscriptionName sets the name of the subscription of the topic
+	// to monitor (function.json).
+	SubscriptionName string
+	// Connection sets the name of the setting that contains the
+	// connection to the Service Bus namespace (function.json).
+	Connection string
+}
 
 // ServiceBusOption is a function that sets options on a Service Bus
 // trigger.
@@ -17,6 +17,11 @@ type Timer struct {
 // TimerOptions contains options for a Timer trigger.
 type TimerOptions struct {
 	Name string
+	// Schedule sets the CRON expression of the trigger (function.json).
+	Schedule string
+	// RunOnStartup sets if the function should be invoked when the
+	// runtime starts (function.json).
+	RunOnStartup bool
 }
 
 // TimerOption is a function that sets options on a Timer trigger.
@@ -1,6 +1,8 @@
 package trigger
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"time"
 )
@@ -27,3 +29,31 @@ type MetadataSys struct {
 	UTCNow     time.Time `json:"UtcNow"`
 	RandGuid   string
 }
+
+// unwrapJSONString unquotes the provided JSON until it is no longer
+// a JSON string.
+func unwrapJSONString(b []byte) ([]byte, error) {
+	b = bytes.TrimSpace(b)
+	for len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, err
+		}
+		b = bytes.TrimSpace([]byte(s))
+	}
+	return b, nil
+}
+
+// unquoteJSON unquotes the provided JSON once if it is a JSON string
+// containing JSON, otherwise it is returned as is.
+func unquoteJSON(b []byte) []byte {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || b[0] != '"' {
+		return b
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil || !json.Valid([]byte(s)) {
+		return b
+	}
+	return []byte(s)
+}
@@ -6,9 +6,11 @@ import (
 	"github.com/KarlGW/azfunc/trigger"
 )
 
-// triggerable is the interface that wraps around the method run.
+// triggerable is the interface that wraps around the methods run
+// and binding.
 type triggerable interface {
 	run(ctx *Context, r *http.Request) error
+	binding() binding
 }
 
 // GenericTriggerFunc represents a generic function to be executed by the function app.
@@ -195,3 +197,239 @@ func EventGridTrigger(name string, fn EventGridTriggerFunc, options ...trigger.E
 		}
 	}
 }
+
+// BlobTriggerFunc represents a Blob Storage trigger function to be executed
+// by the function app.
+type BlobTriggerFunc func(ctx *Context, trigger *trigger.Blob) error
+
+// blobTrigger contains the trigger func, name and options of the trigger.
+type blobTrigger struct {
+	fn      BlobTriggerFunc
+	name    string
+	options []trigger.BlobOption
+}
+
+// run creates the trigger and runs the trigger func.
+func (t blobTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewBlob(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	return t.fn(ctx, tr)
+}
+
+// BlobTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func BlobTrigger(name string, fn BlobTriggerFunc, options ...trigger.BlobOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = blobTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
+
+// EventHubTriggerFunc represents an Event Hub trigger function to be executed
+// by the function app.
+type EventHubTriggerFunc func(ctx *Context, trigger *trigger.EventHub) error
+
+// eventHubTrigger contains the trigger func, name and options of the trigger.
+type eventHubTrigger struct {
+	fn      EventHubTriggerFunc
+	name    string
+	options []trigger.EventHubOption
+}
+
+// run creates the trigger and runs the trigger func.
+func (t eventHubTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewEventHub(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	return t.fn(ctx, tr)
+}
+
+// EventHubTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func EventHubTrigger(name string, fn EventHubTriggerFunc, options ...trigger.EventHubOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = eventHubTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
+
+// CosmosDBTriggerFunc represents a Cosmos DB trigger function to be executed
+// by the function app.
+type CosmosDBTriggerFunc func(ctx *Context, trigger *trigger.CosmosDB) error
+
+// cosmosDBTrigger contains the trigger func, name and options of the trigger.
+type cosmosDBTrigger struct {
+	fn      CosmosDBTriggerFunc
+	name    string
+	options []trigger.CosmosDBOption
+}
+
+// run creates the trigger and runs the trigger func.
+func (t cosmosDBTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewCosmosDB(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	return t.fn(ctx, tr)
+}
+
+// CosmosDBTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func CosmosDBTrigger(name string, fn CosmosDBTriggerFunc, options ...trigger.CosmosDBOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = cosmosDBTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
+
+// KafkaTriggerFunc represents a Kafka trigger function to be executed
+// by the function app.
+type KafkaTriggerFunc func(ctx *Context, trigger *trigger.Kafka) error
+
+// kafkaTrigger contains the trigger func, name and options of the trigger.
+type kafkaTrigger struct {
+	fn      KafkaTriggerFunc
+	name    string
+	options []trigger.KafkaOption
+}
+
+// run creates the trigger and runs the trigger func.
+func (t kafkaTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewKafka(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	return t.fn(ctx, tr)
+}
+
+// KafkaTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func KafkaTrigger(name string, fn KafkaTriggerFunc, options ...trigger.KafkaOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = kafkaTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
+
+// OrchestrationTriggerFunc represents an orchestration trigger function
+// (Durable Functions) to be executed by the function app. The function is
+// replayed from the start for every execution, and should return when
+// awaiting a task results in durable.ErrTaskPending.
+type OrchestrationTriggerFunc func(ctx *Context, trigger *trigger.Orchestration) error
+
+// orchestrationTrigger contains the trigger func, name and options of the trigger.
+type orchestrationTrigger struct {
+	fn      OrchestrationTriggerFunc
+	name    string
+	options []trigger.OrchestrationOption
+}
+
+// run creates the trigger, runs the trigger func and sets the resulting
+// orchestrator state as the return value.
+func (t orchestrationTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewOrchestration(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	ctx.Outputs.SetReturnValue(tr.Run(func() error {
+		return t.fn(ctx, tr)
+	}))
+	return nil
+}
+
+// OrchestrationTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func OrchestrationTrigger(name string, fn OrchestrationTriggerFunc, options ...trigger.OrchestrationOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = orchestrationTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
+
+// ActivityTriggerFunc represents an activity trigger function (Durable Functions)
+// to be executed by the function app. The result of the activity is set with
+// ctx.Outputs.SetReturnValue.
+type ActivityTriggerFunc func(ctx *Context, trigger *trigger.Activity) error
+
+// activityTrigger contains the trigger func, name and options of the trigger.
+type activityTrigger struct {
+	fn      ActivityTriggerFunc
+	name    string
+	options []trigger.ActivityOption
+}
+
+// run creates the trigger and runs the trigger func.
+func (t activityTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewActivity(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	return t.fn(ctx, tr)
+}
+
+// ActivityTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func ActivityTrigger(name string, fn ActivityTriggerFunc, options ...trigger.ActivityOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = activityTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
+
+// EntityTriggerFunc represents an entity trigger function (Durable Functions)
+// to be executed by the function app. The function is run once for every
+// operation sent to the entity.
+type EntityTriggerFunc func(ctx *Context, trigger *trigger.Entity) error
+
+// entityTrigger contains the trigger func, name and options of the trigger.
+type entityTrigger struct {
+	fn      EntityTriggerFunc
+	name    string
+	options []trigger.EntityOption
+}
+
+// run creates the trigger, runs the trigger func for every operation and
+// sets the resulting entity state as the return value.
+func (t entityTrigger) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewEntity(r, t.name, t.options...)
+	if err != nil {
+		return err
+	}
+	ctx.Outputs.SetReturnValue(tr.Run(func() error {
+		return t.fn(ctx, tr)
+	}))
+	return nil
+}
+
+// EntityTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger.
+func EntityTrigger(name string, fn EntityTriggerFunc, options ...trigger.EntityOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = entityTrigger{
+			fn:      fn,
+			name:    name,
+			options: options,
+		}
+	}
+}
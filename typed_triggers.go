@@ -0,0 +1,182 @@
+package azfunc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/KarlGW/azfunc/trigger"
+)
+
+// ErrTriggerDecode is returned when the payload of a trigger could not be
+// decoded into the type of a typed trigger.
+var ErrTriggerDecode = errors.New("trigger payload could not be decoded")
+
+// DecodeErrorHandler handles errors that occur when decoding the payload
+// of a typed trigger. The returned error is returned from the function,
+// if nil the function is considered successful.
+type DecodeErrorHandler func(ctx *Context, err error) error
+
+// TypedTriggerOptions contains options for a typed trigger.
+type TypedTriggerOptions struct {
+	// DecodeErrorHandler is called when the payload could not be decoded.
+	// Defaults to returning the error with BadRequest for HTTP triggers,
+	// and with Permanent for other triggers.
+	DecodeErrorHandler DecodeErrorHandler
+	// HTTPOptions contains options for the underlying HTTP trigger. Only
+	// used by TypedHTTPTrigger.
+	HTTPOptions []trigger.HTTPOption
+	// QueueOptions contains options for the underlying Queue Storage
+	// trigger. Only used by TypedQueueTrigger.
+	QueueOptions []trigger.QueueOption
+	// ServiceBusOptions contains options for the underlying Service Bus
+	// trigger. Only used by TypedServiceBusTrigger.
+	ServiceBusOptions []trigger.ServiceBusOption
+}
+
+// TypedTriggerOption is a function that sets options on a typed trigger.
+type TypedTriggerOption func(o *TypedTriggerOptions)
+
+// WithDecodeErrorHandler sets the handler for errors that occur when
+// decoding the payload of a typed trigger.
+func WithDecodeErrorHandler(fn DecodeErrorHandler) TypedTriggerOption {
+	return func(o *TypedTriggerOptions) {
+		o.DecodeErrorHandler = fn
+	}
+}
+
+// newTypedTriggerOptions creates TypedTriggerOptions from the provided options
+// and sets the decode error handler to the provided default if not set.
+func newTypedTriggerOptions(defaultHandler DecodeErrorHandler, options ...TypedTriggerOption) TypedTriggerOptions {
+	opts := TypedTriggerOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.DecodeErrorHandler == nil {
+		opts.DecodeErrorHandler = defaultHandler
+	}
+	return opts
+}
+
+// permanentDecodeError is the default decode error handler for
+// non-HTTP triggers. It returns the error as a permanent error, since
+// the message would fail to decode again if retried.
+func permanentDecodeError(ctx *Context, err error) error {
+	return Permanent(err)
+}
+
+// badRequestDecodeError is the default decode error handler for
+// HTTP triggers. It returns the error as a bad request, which the
+// error handler responds to with 400 Bad Request.
+func badRequestDecodeError(ctx *Context, err error) error {
+	return BadRequest(err)
+}
+
+// TypedHTTPTriggerFunc represents an HTTP trigger function with the body
+// decoded into T, to be executed by the function app.
+type TypedHTTPTriggerFunc[T any] func(ctx *Context, trigger *trigger.HTTP, v T) error
+
+// typedHTTPTrigger contains the trigger func and options of the trigger.
+type typedHTTPTrigger[T any] struct {
+	fn      TypedHTTPTriggerFunc[T]
+	options TypedTriggerOptions
+}
+
+// run creates the trigger, decodes the body and runs the trigger func.
+func (t typedHTTPTrigger[T]) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewHTTP(r, t.options.HTTPOptions...)
+	if err != nil {
+		return err
+	}
+	var v T
+	if err := tr.Parse(&v); err != nil {
+		return t.options.DecodeErrorHandler(ctx, fmt.Errorf("%w: %w", ErrTriggerDecode, err))
+	}
+	return t.fn(ctx, tr, v)
+}
+
+// TypedHTTPTrigger takes the provided function and sets it as the function
+// to be run by the trigger. The body of the request is decoded into T
+// before the function is called.
+func TypedHTTPTrigger[T any](fn TypedHTTPTriggerFunc[T], options ...TypedTriggerOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = typedHTTPTrigger[T]{
+			fn:      fn,
+			options: newTypedTriggerOptions(badRequestDecodeError, options...),
+		}
+	}
+}
+
+// TypedQueueTriggerFunc represents a Queue Storage trigger function with the
+// message decoded into T, to be executed by the function app.
+type TypedQueueTriggerFunc[T any] func(ctx *Context, trigger *trigger.Queue, v T) error
+
+// typedQueueTrigger contains the trigger func, name and options of the trigger.
+type typedQueueTrigger[T any] struct {
+	fn      TypedQueueTriggerFunc[T]
+	name    string
+	options TypedTriggerOptions
+}
+
+// run creates the trigger, decodes the message and runs the trigger func.
+func (t typedQueueTrigger[T]) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewQueue(r, t.name, t.options.QueueOptions...)
+	if err != nil {
+		return err
+	}
+	var v T
+	if err := tr.Parse(&v); err != nil {
+		return t.options.DecodeErrorHandler(ctx, fmt.Errorf("%w: %w", ErrTriggerDecode, err))
+	}
+	return t.fn(ctx, tr, v)
+}
+
+// TypedQueueTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger. The message is decoded into T
+// before the function is called.
+func TypedQueueTrigger[T any](name string, fn TypedQueueTriggerFunc[T], options ...TypedTriggerOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = typedQueueTrigger[T]{
+			fn:      fn,
+			name:    name,
+			options: newTypedTriggerOptions(permanentDecodeError, options...),
+		}
+	}
+}
+
+// TypedServiceBusTriggerFunc represents a Service Bus trigger function with the
+// message decoded into T, to be executed by the function app.
+type TypedServiceBusTriggerFunc[T any] func(ctx *Context, trigger *trigger.ServiceBus, v T) error
+
+// typedServiceBusTrigger contains the trigger func, name and options of the trigger.
+type typedServiceBusTrigger[T any] struct {
+	fn      TypedServiceBusTriggerFunc[T]
+	name    string
+	options TypedTriggerOptions
+}
+
+// run creates the trigger, decodes the message and runs the trigger func.
+func (t typedServiceBusTrigger[T]) run(ctx *Context, r *http.Request) error {
+	tr, err := trigger.NewServiceBus(r, t.name, t.options.ServiceBusOptions...)
+	if err != nil {
+		return err
+	}
+	var v T
+	if err := tr.Parse(&v); err != nil {
+		return t.options.DecodeErrorHandler(ctx, fmt.Errorf("%w: %w", ErrTriggerDecode, err))
+	}
+	return t.fn(ctx, tr, v)
+}
+
+// TypedServiceBusTrigger takes the provided name and function and sets it as
+// the function to be run by the trigger. The message is decoded into T
+// before the function is called.
+func TypedServiceBusTrigger[T any](name string, fn TypedServiceBusTriggerFunc[T], options ...TypedTriggerOption) FunctionOption {
+	return func(f *function) {
+		f.trigger = typedServiceBusTrigger[T]{
+			fn:      fn,
+			name:    name,
+			options: newTypedTriggerOptions(permanentDecodeError, options...),
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package azfunc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/KarlGW/azfunc/trigger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type typedMessage struct {
+	Message string `json:"message"`
+	Number  int    `json:"number"`
+}
+
+func TestTypedHTTPTrigger(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			body    []byte
+			options []TypedTriggerOption
+		}
+		want           typedMessage
+		wantStatusCode int
+		wantErr        error
+		wantErrStatus  int
+	}{
+		{
+			name: "decoded",
+			input: struct {
+				body    []byte
+				options []TypedTriggerOption
+			}{
+				body: []byte(`{"Data":{"req":{"Body":"{\"message\":\"hello\",\"number\":2}"}}}`),
+			},
+			want:           typedMessage{Message: "hello", Number: 2},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "decode error",
+			input: struct {
+				body    []byte
+				options []TypedTriggerOption
+			}{
+				body: []byte(`{"Data":{"req":{"Body":"{\"message\":1}"}}}`),
+			},
+			wantStatusCode: http.StatusOK,
+			wantErr:        ErrTriggerDecode,
+			wantErrStatus:  http.StatusBadRequest,
+		},
+		{
+			name: "decode error with handler",
+			input: struct {
+				body    []byte
+				options []TypedTriggerOption
+			}{
+				body: []byte(`{"Data":{"req":{"Body":"{\"message\":1}"}}}`),
+				options: []TypedTriggerOption{
+					WithDecodeErrorHandler(func(ctx *Context, err error) error {
+						return err
+					}),
+				},
+			},
+			wantStatusCode: http.StatusOK,
+			wantErr:        ErrTriggerDecode,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got typedMessage
+			f := function{}
+			TypedHTTPTrigger(func(ctx *Context, trigger *trigger.HTTP, v typedMessage) error {
+				got = v
+				return nil
+			}, test.input.options...)(&f)
+
+			ctx := newContext(context.Background(), func(o *contextOptions) {
+				o.outputs = newOutputs()
+			})
+			gotErr := f.trigger.run(ctx, &http.Request{Body: io.NopCloser(bytes.NewReader(test.input.body))})
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("TypedHTTPTrigger() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("TypedHTTPTrigger() = unexpected error (-want +got)\n%s\n", diff)
+			}
+			if ctx.Outputs.HTTP().StatusCode() != test.wantStatusCode {
+				t.Errorf("TypedHTTPTrigger() = unexpected status code, want: %d, got: %d\n", test.wantStatusCode, ctx.Outputs.HTTP().StatusCode())
+			}
+			var gotErrStatus int
+			var e *Error
+			if errors.As(gotErr, &e) {
+				gotErrStatus = e.StatusCode
+			}
+			if diff := cmp.Diff(test.wantErrStatus, gotErrStatus); diff != "" {
+				t.Errorf("TypedHTTPTrigger() = unexpected error status code (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTypedQueueTrigger(t *testing.T) {
+	var tests = []struct {
+		name          string
+		input         []byte
+		want          typedMessage
+		wantErr       error
+		wantPermanent bool
+	}{
+		{
+			name:  "decoded",
+			input: []byte(`{"Data":{"queue":"{\"message\":\"hello\",\"number\":2}"},"Metadata":{}}`),
+			want:  typedMessage{Message: "hello", Number: 2},
+		},
+		{
+			name:          "decode error",
+			input:         []byte(`{"Data":{"queue":"{\"message\":1}"},"Metadata":{}}`),
+			wantErr:       ErrTriggerDecode,
+			wantPermanent: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got typedMessage
+			f := function{}
+			TypedQueueTrigger("queue", func(ctx *Context, trigger *trigger.Queue, v typedMessage) error {
+				got = v
+				return nil
+			})(&f)
+
+			ctx := newContext(context.Background(), func(o *contextOptions) {
+				o.outputs = newOutputs()
+			})
+			gotErr := f.trigger.run(ctx, &http.Request{Body: io.NopCloser(bytes.NewReader(test.input))})
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("TypedQueueTrigger() = unexpected result (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("TypedQueueTrigger() = unexpected error (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.wantPermanent, IsPermanent(gotErr)); diff != "" {
+				t.Errorf("TypedQueueTrigger() = unexpected permanent error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTypedServiceBusTrigger(t *testing.T) {
+	wantErr := errors.New("handled")
+
+	var got typedMessage
+	f := function{}
+	TypedServiceBusTrigger("message", func(ctx *Context, trigger *trigger.ServiceBus, v typedMessage) error {
+		got = v
+		return nil
+	}, WithDecodeErrorHandler(func(ctx *Context, err error) error {
+		return wantErr
+	}))(&f)
+
+	ctx := newContext(context.Background(), func(o *contextOptions) {
+		o.outputs = newOutputs()
+	})
+
+	gotErr := f.trigger.run(ctx, &http.Request{Body: io.NopCloser(bytes.NewReader([]byte(`{"Data":{"message":"{\"message\":\"hello\",\"number\":2}"},"Metadata":{}}`)))})
+	if gotErr != nil {
+		t.Errorf("TypedServiceBusTrigger() = unexpected error: %v\n", gotErr)
+	}
+	if diff := cmp.Diff(typedMessage{Message: "hello", Number: 2}, got); diff != "" {
+		t.Errorf("TypedServiceBusTrigger() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	gotErr = f.trigger.run(ctx, &http.Request{Body: io.NopCloser(bytes.NewReader([]byte(`{"Data":{"message":"[]"},"Metadata":{}}`)))})
+	if diff := cmp.Diff(wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("TypedServiceBusTrigger() = unexpected error (-want +got)\n%s\n", diff)
+	}
+}
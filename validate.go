@@ -0,0 +1,97 @@
+package azfunc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrFunctionConfigNotFound is returned when the function.json of a
+	// registered function could not be found.
+	ErrFunctionConfigNotFound = errors.New("function.json not found")
+	// ErrFunctionConfigMalformed is returned when the function.json of a
+	// registered function could not be parsed.
+	ErrFunctionConfigMalformed = errors.New("function.json malformed")
+	// ErrBindingNotFound is returned when a binding of a registered function
+	// is not declared in its function.json.
+	ErrBindingNotFound = errors.New("binding not found in function.json")
+)
+
+// Validate the function.json of every registered function in the provided
+// directory against the registered functions. It checks that the function.json
+// exists, that the trigger is declared with the same name and type, and that
+// the durable client and output bindings are declared. If dir is empty, the
+// directory of host.json of the FunctionApp (defaults to the directory of the
+// executable) is used. All found errors are returned joined.
+func (a functionApp) Validate(dir string) error {
+	if len(a.functions) == 0 {
+		return ErrNoFunction
+	}
+	if len(dir) == 0 {
+		dir = a.configDir
+	}
+	if len(dir) == 0 {
+		dir = defaultConfigDir()
+	}
+
+	names := make([]string, 0, len(a.functions))
+	for name := range a.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := a.functions[name].validate(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate the function against its function.json in the provided
+// directory.
+func (f function) validate(dir string) error {
+	b, err := os.ReadFile(filepath.Join(dir, f.name, "function.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: function %s", ErrFunctionConfigNotFound, f.name)
+		}
+		return err
+	}
+
+	var config functionConfig
+	if err := json.Unmarshal(b, &config); err != nil {
+		return fmt.Errorf("%w: function %s: %w", ErrFunctionConfigMalformed, f.name, err)
+	}
+
+	var errs []error
+	for _, want := range f.bindings() {
+		if !config.declares(want) {
+			errs = append(errs, fmt.Errorf("%w: function %s: %s binding %q of type %q", ErrBindingNotFound, f.name, want.Direction, want.Name, want.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// declares returns true if the function.json declares a binding with the
+// same name, direction and type (if set) as the provided binding.
+func (c functionConfig) declares(want binding) bool {
+	for _, b := range c.Bindings {
+		if !strings.EqualFold(b.Direction, want.Direction) {
+			continue
+		}
+		if len(want.Type) > 0 && !strings.EqualFold(b.Type, want.Type) {
+			continue
+		}
+		if b.Name == want.Name || (want.Type == "http" && want.Direction == bindingDirectionOut && b.Name == "$return") {
+			return true
+		}
+	}
+	return false
+}
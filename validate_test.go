@@ -0,0 +1,121 @@
+package azfunc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KarlGW/azfunc/output"
+)
+
+func TestFunctionApp_Validate(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			functions map[string][]byte
+			options   []FunctionOption
+		}
+		wantErr []error
+	}{
+		{
+			name: "valid",
+			input: struct {
+				functions map[string][]byte
+				options   []FunctionOption
+			}{
+				functions: map[string][]byte{
+					"hello-queue": []byte(`{"bindings":[{"name":"queue","type":"queueTrigger","direction":"in"},{"name":"outqueue","type":"queue","direction":"out"}]}`),
+				},
+				options: []FunctionOption{
+					QueueTrigger("queue", nil),
+					WithOutput(output.NewQueue("outqueue")),
+				},
+			},
+		},
+		{
+			name: "function.json not found",
+			input: struct {
+				functions map[string][]byte
+				options   []FunctionOption
+			}{
+				options: []FunctionOption{
+					QueueTrigger("queue", nil),
+				},
+			},
+			wantErr: []error{ErrFunctionConfigNotFound},
+		},
+		{
+			name: "function.json malformed",
+			input: struct {
+				functions map[string][]byte
+				options   []FunctionOption
+			}{
+				functions: map[string][]byte{
+					"hello-queue": []byte(`{"bindings":`),
+				},
+				options: []FunctionOption{
+					QueueTrigger("queue", nil),
+				},
+			},
+			wantErr: []error{ErrFunctionConfigMalformed},
+		},
+		{
+			name: "trigger name and output mismatch",
+			input: struct {
+				functions map[string][]byte
+				options   []FunctionOption
+			}{
+				functions: map[string][]byte{
+					"hello-queue": []byte(`{"bindings":[{"name":"items","type":"queueTrigger","direction":"in"}]}`),
+				},
+				options: []FunctionOption{
+					QueueTrigger("queue", nil),
+					WithOutput(output.NewQueue("outqueue")),
+				},
+			},
+			wantErr: []error{ErrBindingNotFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, b := range test.input.functions {
+				if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, name, "function.json"), b, 0644); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			app := NewFunctionApp()
+			app.AddFunction("hello-queue", test.input.options...)
+
+			gotErr := app.Validate(dir)
+			if len(test.wantErr) == 0 && gotErr != nil {
+				t.Errorf("Validate() = unexpected error: %v\n", gotErr)
+			}
+			for _, wantErr := range test.wantErr {
+				if !errors.Is(gotErr, wantErr) {
+					t.Errorf("Validate() = unexpected error, want: %v, got: %v\n", wantErr, gotErr)
+				}
+			}
+		})
+	}
+}
+
+func TestFunctionApp_Validate_Generated(t *testing.T) {
+	dir := t.TempDir()
+
+	app := NewFunctionApp()
+	app.AddFunction("hello-http", HTTPTrigger(nil), WithDurableClient("starter"))
+	if err := app.GenerateConfig(dir); err != nil {
+		t.Fatalf("GenerateConfig() = unexpected error: %v", err)
+	}
+
+	if err := app.Validate(dir); err != nil {
+		t.Errorf("Validate() = unexpected error: %v\n", err)
+	}
+}